@@ -0,0 +1,71 @@
+package goflyway
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// ChecksumAlgorithm identifies how script checksums stored in the schema history table are computed
+type ChecksumAlgorithm string
+
+const (
+	// ChecksumSHA256 is the hex encoded SHA-256 of the script, see CalculateChecksum
+	ChecksumSHA256 ChecksumAlgorithm = "sha256"
+
+	// ChecksumCRC32 is the line-based CRC32 of Java Flyway, see CalculateFlywayChecksum. It ignores line endings
+	// and the byte order mark
+	ChecksumCRC32 ChecksumAlgorithm = "crc32"
+)
+
+var utf8BOM = []byte("\xef\xbb\xbf")
+
+// normalizeScript Prepare script content for checksums: with normalize the byte order mark is stripped and CRLF line
+// endings become LF, with trimTrailingWhitespace the spaces, tabs and carriage returns ending each line are removed
+func normalizeScript(content []byte, normalize bool, trimTrailingWhitespace bool) []byte {
+
+	if normalize {
+		content = bytes.TrimPrefix(content, utf8BOM)
+		content = bytes.ReplaceAll(content, []byte("\r\n"), []byte("\n"))
+	}
+
+	if trimTrailingWhitespace {
+		lines := bytes.Split(content, []byte("\n"))
+		for i, line := range lines {
+			lines[i] = bytes.TrimRight(line, " \t\r")
+		}
+		content = bytes.Join(lines, []byte("\n"))
+	}
+
+	return content
+}
+
+// scriptChecksum Returns the checksum of script content with the given algorithm, as stored in the schema history table
+func scriptChecksum(content []byte, algorithm ChecksumAlgorithm) (string, error) {
+
+	switch algorithm {
+	case ChecksumSHA256:
+		sum := sha256.Sum256(content)
+		return hex.EncodeToString(sum[:]), nil
+	case ChecksumCRC32:
+		return strconv.Itoa(int(flywayChecksum(content))), nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedChecksumAlgorithm, algorithm)
+	}
+}
+
+// calculateChecksum Returns the checksum of the script as stored in the schema history table
+func (g *goFlywayRunner) calculateChecksum(filename string) (string, error) {
+
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return "", throwErrMigration(err)
+	}
+
+	b = normalizeScript(b, g.config.NormalizeChecksums, g.config.ChecksumTrimTrailingWhitespace)
+
+	return scriptChecksum(b, g.config.ChecksumAlgorithm)
+}
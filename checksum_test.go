@@ -0,0 +1,91 @@
+package goflyway
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalizeScript(t *testing.T) {
+
+	lf := []byte("CREATE TABLE a (id INT);\nINSERT INTO a VALUES (1);\n")
+	crlf := []byte("\xef\xbb\xbfCREATE TABLE a (id INT);\r\nINSERT INTO a VALUES (1);\r\n")
+	trailing := []byte("CREATE TABLE a (id INT);  \nINSERT INTO a VALUES (1);\t\n")
+
+	tests := []struct {
+		name     string
+		content  []byte
+		normal   bool
+		trim     bool
+		expected bool
+	}{
+		{"raw CRLF", crlf, false, false, false},
+		{"normalized CRLF", crlf, true, false, true},
+		{"raw trailing whitespace", trailing, true, false, false},
+		{"trimmed trailing whitespace", trailing, false, true, true},
+	}
+
+	for _, tt := range tests {
+
+		expected, _ := scriptChecksum(lf, ChecksumSHA256)
+		got, err := scriptChecksum(normalizeScript(tt.content, tt.normal, tt.trim), ChecksumSHA256)
+		if err != nil {
+			t.Fatalf("%s: expected nil but got error %v", tt.name, err)
+		}
+
+		if (got == expected) != tt.expected {
+			t.Errorf("%s: expected checksum match %v but got %s and %s", tt.name, tt.expected, got, expected)
+		}
+	}
+}
+
+func TestScriptChecksum(t *testing.T) {
+
+	content := []byte("SELECT 1;\n")
+
+	sha, err := CalculateChecksum(getWorkPath() + "/utils/test/db/migration/sqlite3/V1__test_create_table_product.sql")
+	if err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	if len(sha) != 64 {
+		t.Errorf("expected hex encoded sha256 but got %s", sha)
+	}
+
+	crc, err := scriptChecksum(content, ChecksumCRC32)
+	if err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	if crlf, _ := scriptChecksum([]byte("SELECT 1;\r\n"), ChecksumCRC32); crlf != crc {
+		t.Errorf("expected crc32 checksum to ignore line endings but got %s and %s", crlf, crc)
+	}
+
+	_, err = scriptChecksum(content, "md5")
+	if !errors.Is(err, ErrUnsupportedChecksumAlgorithm) {
+		t.Errorf("expected ErrUnsupportedChecksumAlgorithm but got %v", err)
+	}
+}
+
+func TestChecksumAlgorithmSettings(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		config   GoFlywayConfig
+		expected error
+	}{
+		{"unknown algorithm", GoFlywayConfig{ChecksumAlgorithm: "md5"}, ErrUnsupportedChecksumAlgorithm},
+		{"sha256 on Flyway table", GoFlywayConfig{ChecksumAlgorithm: ChecksumSHA256, FlywayCompatible: true}, ErrUnsupportedChecksumAlgorithm},
+		{"crc32", GoFlywayConfig{ChecksumAlgorithm: ChecksumCRC32}, nil},
+	}
+
+	for _, tt := range tests {
+
+		tt.config.Driver = SQLITE3
+		tt.config.Location = "."
+
+		_, err := newGoFlywayRunner(tt.config)
+		if !errors.Is(err, tt.expected) {
+			t.Errorf("%s: expected %v but got %v", tt.name, tt.expected, err)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gabrielaraujosouza/goflyway"
+)
+
+func runMigrate(c goflyway.GoFlywayConfig, args []string, stdout io.Writer) error {
+
+	result, err := goflyway.Migrate(c)
+	if result != nil {
+		for _, m := range result.Migrations {
+			fmt.Fprintf(stdout, "Migrated to version %s - %s (%v)\n", m.Version, m.Description, m.ExecutionTime)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(stdout, "Applied %d migrations, schema version %s\n", result.MigrationsExecuted, displayVersion(result.TargetSchemaVersion))
+
+	return nil
+}
+
+func runInfo(c goflyway.GoFlywayConfig, args []string, stdout io.Writer) error {
+
+	infos, err := goflyway.Info(c)
+	if err != nil {
+		return err
+	}
+
+	rows := [][]string{}
+	for _, i := range infos {
+
+		installedOn := ""
+		if i.InstalledOn != nil {
+			installedOn = i.InstalledOn.Local().Format("2006-01-02 15:04:05")
+		}
+
+		rows = append(rows, []string{i.Version, i.Description, i.Type, installedOn, string(i.State)})
+	}
+
+	printTable(stdout, []string{"Version", "Description", "Type", "Installed On", "State"}, rows)
+
+	return nil
+}
+
+func runValidate(c goflyway.GoFlywayConfig, args []string, stdout io.Writer) error {
+
+	if err := goflyway.Validate(c); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(stdout, "Successfully validated migrations")
+
+	return nil
+}
+
+func runBaseline(c goflyway.GoFlywayConfig, args []string, stdout io.Writer) error {
+
+	if err := goflyway.Baseline(c); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(stdout, "Successfully baselined schema")
+
+	return nil
+}
+
+func runRepair(c goflyway.GoFlywayConfig, args []string, stdout io.Writer) error {
+
+	result, err := goflyway.Repair(c)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range result.MigrationsRemoved {
+		fmt.Fprintf(stdout, "Removed failed migration %s - %s\n", m.Version, m.Description)
+	}
+	for _, m := range result.MigrationsAligned {
+		fmt.Fprintf(stdout, "Realigned migration %s - %s\n", m.Version, m.Description)
+	}
+	fmt.Fprintf(stdout, "Successfully repaired schema history table, %d removed, %d realigned\n",
+		len(result.MigrationsRemoved), len(result.MigrationsAligned))
+
+	return nil
+}
+
+func runClean(c goflyway.GoFlywayConfig, args []string, stdout io.Writer) error {
+
+	if err := goflyway.Clean(c); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(stdout, "Successfully cleaned schemas")
+
+	return nil
+}
+
+func runNew(c goflyway.GoFlywayConfig, args []string, stdout io.Writer) error {
+
+	path, err := goflyway.NewMigration(c, strings.Join(args, " "))
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(stdout, "Created %s\n", path)
+
+	return nil
+}
+
+func runLint(c goflyway.GoFlywayConfig, args []string, stdout io.Writer) error {
+
+	// the rules depend on the database, no connection is made
+	if len(c.Driver) <= 0 {
+		return fmt.Errorf("%w: missing -driver or GOFLYWAY_DRIVER", goflyway.ErrInvalidConfig)
+	}
+
+	result, err := goflyway.Lint(c)
+	if result == nil {
+		return err
+	}
+
+	for _, f := range result.Findings {
+		fmt.Fprintf(stdout, "%s:%d: %s %s: %s\n    %s\n", f.Script, f.Line, f.Severity, f.Rule, f.Message, f.Statement)
+	}
+	fmt.Fprintf(stdout, "Linted %d migrations, %d errors, %d warnings\n",
+		result.ScriptsLinted, result.Errors(), len(result.Findings)-result.Errors())
+
+	return err
+}
+
+func displayVersion(version string) string {
+
+	if len(version) <= 0 {
+		return "<< Empty Schema >>"
+	}
+
+	return version
+}
+
+// printTable Print rows as a bordered text table
+func printTable(w io.Writer, header []string, rows [][]string) {
+
+	widths := make([]int, len(header))
+	for i, h := range header {
+		widths[i] = utf8.RuneCountInString(h)
+	}
+	for _, r := range rows {
+		for i, v := range r {
+			if n := utf8.RuneCountInString(v); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+
+	border := "+"
+	for _, width := range widths {
+		border += strings.Repeat("-", width+2) + "+"
+	}
+
+	printRow := func(values []string) {
+		line := "|"
+		for i, v := range values {
+			line += " " + v + strings.Repeat(" ", widths[i]-utf8.RuneCountInString(v)) + " |"
+		}
+		fmt.Fprintln(w, line)
+	}
+
+	fmt.Fprintln(w, border)
+	printRow(header)
+	fmt.Fprintln(w, border)
+	if len(rows) <= 0 {
+		padding := len(border) - 21
+		if padding < 1 {
+			padding = 1
+		}
+		fmt.Fprintln(w, "| No migrations found"+strings.Repeat(" ", padding)+"|")
+	}
+	for _, r := range rows {
+		printRow(r)
+	}
+	fmt.Fprintln(w, border)
+}
@@ -0,0 +1,9 @@
+package main
+
+// database/sql drivers opened by goflyway.Open for the built-in GoFlyway drivers
+import (
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	_ "github.com/microsoft/go-mssqldb"
+	_ "modernc.org/sqlite"
+)
@@ -0,0 +1,157 @@
+// Command goflyway runs GoFlyway migrations from the command line.
+//
+// Usage:
+//
+//	goflyway <command> [flags]
+//
+// The commands are migrate, info, validate, baseline, repair, clean, new and lint. Settings are read from the configuration
+// file, then the environment variables shown by "goflyway <command> -h", then the flags, each overriding the previous.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/gabrielaraujosouza/goflyway"
+)
+
+// Exit codes returned by the commands
+const (
+	exitOK         = 0
+	exitError      = 1
+	exitUsage      = 2
+	exitConnection = 3
+	exitValidation = 4
+	exitMigration  = 5
+	exitDisabled   = 6
+)
+
+// command a subcommand run with the parsed configuration and the arguments left after the flags
+type command struct {
+	description string
+
+	// arguments shown in the usage, commands without arguments reject them
+	arguments string
+
+	// whether the command connects to the database
+	database bool
+
+	run func(c goflyway.GoFlywayConfig, args []string, stdout io.Writer) error
+}
+
+var commands = map[string]command{
+	"migrate":  {"Apply the pending migrations", "", true, runMigrate},
+	"info":     {"Print the applied and pending migrations", "", true, runInfo},
+	"validate": {"Validate the applied migrations against the local scripts", "", true, runValidate},
+	"baseline": {"Mark an existing database at the baseline version", "", true, runBaseline},
+	"repair":   {"Remove failed migrations and realign checksums of the schema history table", "", true, runRepair},
+	"clean":    {"Drop all objects of the configured schemas", "", true, runClean},
+	"new":      {"Create an empty migration script for the next version", "<description>", false, runNew},
+	"lint":     {"Check the migration scripts for statements that lose data or lock tables", "", false, runLint},
+}
+
+var commandNames = []string{"migrate", "info", "validate", "baseline", "repair", "clean", "new", "lint"}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run Execute the command line and returns the exit code
+func run(args []string, stdout io.Writer, stderr io.Writer) int {
+
+	if len(args) <= 0 || args[0] == "-h" || args[0] == "--help" || args[0] == "help" {
+		usage(stderr)
+		return exitUsage
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "goflyway: unknown command %q\n\n", args[0])
+		usage(stderr)
+		return exitUsage
+	}
+
+	fs := flag.NewFlagSet("goflyway "+args[0], flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	opts := &options{}
+	opts.register(fs)
+
+	if err := fs.Parse(args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+
+	if (fs.NArg() > 0) != (len(cmd.arguments) > 0) {
+		fmt.Fprintf(stderr, "goflyway: usage: goflyway %s [flags] %s\n", args[0], cmd.arguments)
+		return exitUsage
+	}
+
+	c, err := opts.config(cmd.database)
+	if err != nil {
+		fmt.Fprintf(stderr, "goflyway: %v\n", err)
+		return exitUsage
+	}
+
+	// with CreateDatabase the database may not exist yet, goflyway creates it and connects to the DSN itself
+	if cmd.database && !c.CreateDatabase {
+		db, err := goflyway.Open(c)
+		if err != nil {
+			fmt.Fprintf(stderr, "goflyway: %v\n", err)
+			return exitConnection
+		}
+		defer db.Close()
+		c.Db = db
+	}
+
+	if err = cmd.run(c, fs.Args(), stdout); err != nil {
+		fmt.Fprintf(stderr, "goflyway: %v\n", err)
+		return exitCode(err)
+	}
+
+	return exitOK
+}
+
+func usage(w io.Writer) {
+
+	fmt.Fprintf(w, "Usage: goflyway <command> [flags]\n\nCommands:\n")
+	for _, name := range commandNames {
+		fmt.Fprintf(w, "  %-24s %s\n", strings.TrimSpace(name+" "+commands[name].arguments), commands[name].description)
+	}
+	fmt.Fprintf(w, "\nRun \"goflyway <command> -h\" for the flags of a command.\n")
+}
+
+// exitCode Map the error of a command to the exit code
+func exitCode(err error) int {
+
+	switch {
+	case errors.Is(err, goflyway.ErrChecksumMismatch),
+		errors.Is(err, goflyway.ErrDuplicateVersion),
+		errors.Is(err, goflyway.ErrDescriptionMismatch),
+		errors.Is(err, goflyway.ErrOutOfOrder),
+		errors.Is(err, goflyway.ErrMissingMigration),
+		errors.Is(err, goflyway.ErrBaselineNotEmpty),
+		errors.Is(err, goflyway.ErrLintFailed):
+		return exitValidation
+	case errors.Is(err, goflyway.ErrScriptExecution):
+		return exitMigration
+	case errors.Is(err, goflyway.ErrDatabaseConnection):
+		return exitConnection
+	case errors.Is(err, goflyway.ErrCleanDisabled):
+		return exitDisabled
+	case errors.Is(err, goflyway.ErrUnsupportedDatabaseDriver),
+		errors.Is(err, goflyway.ErrInvalidConfig),
+		errors.Is(err, goflyway.ErrInvalidMigrationName),
+		errors.Is(err, goflyway.ErrLocationCannotBeEmpty),
+		errors.Is(err, goflyway.ErrUnsupportedChecksumAlgorithm):
+		return exitUsage
+	default:
+		return exitError
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// sqlite3Args Returns the flags of a sqlite database file private to the test
+func sqlite3Args(t *testing.T) []string {
+
+	_, file, _, _ := runtime.Caller(0)
+	location := filepath.Join(filepath.Dir(file), "..", "..", "utils", "test", "db", "migration", "sqlite3")
+
+	return []string{
+		"-driver", "sqlite3",
+		"-dsn", filepath.Join(t.TempDir(), "goflyway.db"),
+		"-location", location,
+	}
+}
+
+func runCommand(t *testing.T, args ...string) (int, string, string) {
+
+	var stdout, stderr bytes.Buffer
+	code := run(args, &stdout, &stderr)
+
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRunMigrateAndInfo(t *testing.T) {
+
+	args := sqlite3Args(t)
+
+	code, stdout, stderr := runCommand(t, append([]string{"info"}, args...)...)
+	if code != exitOK {
+		t.Fatalf("expected exit code %d but got %d: %s", exitOK, code, stderr)
+	}
+
+	if strings.Count(stdout, "Pending") != 3 {
+		t.Errorf("expected 3 pending migrations but got\n%s", stdout)
+	}
+
+	code, stdout, stderr = runCommand(t, append([]string{"migrate"}, args...)...)
+	if code != exitOK {
+		t.Fatalf("expected exit code %d but got %d: %s", exitOK, code, stderr)
+	}
+
+	if !strings.Contains(stdout, "Applied 3 migrations, schema version 3") {
+		t.Errorf("expected 3 applied migrations but got\n%s", stdout)
+	}
+
+	code, stdout, _ = runCommand(t, append([]string{"info"}, args...)...)
+	if code != exitOK || strings.Count(stdout, "Success") != 3 {
+		t.Errorf("expected 3 applied migrations but got exit code %d\n%s", code, stdout)
+	}
+
+	code, _, stderr = runCommand(t, append([]string{"validate"}, args...)...)
+	if code != exitOK {
+		t.Errorf("expected exit code %d but got %d: %s", exitOK, code, stderr)
+	}
+}
+
+func TestRunExitCodes(t *testing.T) {
+
+	args := sqlite3Args(t)
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected int
+	}{
+		{"no command", nil, exitUsage},
+		{"unknown command", []string{"upgrade"}, exitUsage},
+		{"missing driver", []string{"migrate", "-dsn", "x", "-location", "."}, exitUsage},
+		{"unsupported driver", []string{"migrate", "-driver", "oracle", "-dsn", "x", "-location", "."}, exitConnection},
+		{"clean disabled", append([]string{"clean"}, args...), exitDisabled},
+		{"baseline", append([]string{"baseline"}, args...), exitOK},
+		{"baseline twice", append([]string{"baseline"}, args...), exitValidation},
+		{"clean", append([]string{"clean", "-clean-enabled"}, args...), exitOK},
+	}
+
+	for _, tt := range tests {
+		if code, _, stderr := runCommand(t, tt.args...); code != tt.expected {
+			t.Errorf("%s: expected exit code %d but got %d: %s", tt.name, tt.expected, code, stderr)
+		}
+	}
+}
+
+func TestRunEnvironment(t *testing.T) {
+
+	args := sqlite3Args(t)
+	t.Setenv("GOFLYWAY_DRIVER", args[1])
+	t.Setenv("GOFLYWAY_DSN", args[3])
+	t.Setenv("GOFLYWAY_LOCATION", args[5])
+
+	if code, _, stderr := runCommand(t, "migrate"); code != exitOK {
+		t.Errorf("expected exit code %d but got %d: %s", exitOK, code, stderr)
+	}
+
+	t.Setenv("GOFLYWAY_OUT_OF_ORDER", "sometimes")
+
+	code, _, stderr := runCommand(t, "migrate")
+	if code != exitUsage || !strings.Contains(stderr, "GOFLYWAY_OUT_OF_ORDER") {
+		t.Errorf("expected exit code %d naming GOFLYWAY_OUT_OF_ORDER but got %d: %s", exitUsage, code, stderr)
+	}
+}
+
+func TestRunConfigFile(t *testing.T) {
+
+	args := sqlite3Args(t)
+	config := filepath.Join(t.TempDir(), "goflyway.yaml")
+
+	content := fmt.Sprintf("driver: %s\ndsn: %s\nlocation: %s\ntable: history\n", args[1], args[3], args[5])
+	if err := os.WriteFile(config, []byte(content), 0o644); err != nil {
+		t.Fatalf("errors happened when writing configuration file: %v", err)
+	}
+
+	// flags override the configuration file
+	code, stdout, stderr := runCommand(t, "info", "-config", config, "-table", "cli_history")
+	if code != exitOK || strings.Count(stdout, "Pending") != 3 {
+		t.Fatalf("expected 3 pending migrations but got exit code %d: %s%s", code, stdout, stderr)
+	}
+
+	if code, _, stderr = runCommand(t, "migrate", "-config", config, "-location", "/does/not/exist"); code != exitError {
+		t.Errorf("expected exit code %d but got %d: %s", exitError, code, stderr)
+	}
+}
+
+func TestRunNew(t *testing.T) {
+
+	dir := t.TempDir()
+
+	code, stdout, stderr := runCommand(t, "new", "-location", dir, "create", "product")
+	if code != exitOK || !strings.Contains(stdout, "V1__create_product.sql") {
+		t.Fatalf("expected V1__create_product.sql but got exit code %d: %s%s", code, stdout, stderr)
+	}
+
+	if code, _, _ = runCommand(t, "new", "-location", dir); code != exitUsage {
+		t.Errorf("expected exit code %d without description but got %d", exitUsage, code)
+	}
+
+	if code, _, _ = runCommand(t, "info", "-location", dir, "unexpected"); code != exitUsage {
+		t.Errorf("expected exit code %d with unexpected arguments but got %d", exitUsage, code)
+	}
+}
+
+func TestPrintTable(t *testing.T) {
+
+	var out bytes.Buffer
+	printTable(&out, []string{"Version", "State"}, [][]string{{"1", "Success"}, {"10", "Pending"}})
+
+	expected := "+---------+---------+\n" +
+		"| Version | State   |\n" +
+		"+---------+---------+\n" +
+		"| 1       | Success |\n" +
+		"| 10      | Pending |\n" +
+		"+---------+---------+\n"
+
+	if out.String() != expected {
+		t.Errorf("expected table\n%s\nbut got\n%s", expected, out.String())
+	}
+}
+
+func TestRunLint(t *testing.T) {
+
+	dir := t.TempDir()
+
+	script := "ALTER TABLE product DROP COLUMN description;\n"
+	if err := os.WriteFile(filepath.Join(dir, "V1__drop_description.sql"), []byte(script), 0o644); err != nil {
+		t.Fatalf("errors happened when writing migration: %v", err)
+	}
+
+	code, stdout, stderr := runCommand(t, "lint", "-driver", "postgres", "-location", dir)
+	if code != exitValidation || !strings.Contains(stdout, "V1__drop_description.sql:1: error drop-column") {
+		t.Fatalf("expected drop-column error with exit code %d but got %d: %s%s", exitValidation, code, stdout, stderr)
+	}
+
+	t.Setenv("GOFLYWAY_LINT_RULES_DROP_COLUMN", "warning")
+
+	code, stdout, stderr = runCommand(t, "lint", "-driver", "postgres", "-location", dir)
+	if code != exitOK || !strings.Contains(stdout, "0 errors, 1 warnings") {
+		t.Errorf("expected drop-column warning with exit code %d but got %d: %s%s", exitOK, code, stdout, stderr)
+	}
+
+	if code, _, _ = runCommand(t, "lint", "-location", dir); code != exitUsage {
+		t.Errorf("expected exit code %d without driver but got %d", exitUsage, code)
+	}
+}
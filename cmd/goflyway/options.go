@@ -0,0 +1,146 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/gabrielaraujosouza/goflyway"
+)
+
+// options command line flags. They override the configuration file and the GOFLYWAY_* environment variables
+// read by goflyway.LoadConfig
+type options struct {
+	configFile              string
+	dsn                     string
+	driver                  string
+	location                string
+	table                   string
+	schemas                 string
+	defaultSchema           string
+	createSchemas           bool
+	outOfOrder              bool
+	ignoreMissingMigrations bool
+	flywayCompatible        bool
+	checksumAlgorithm       string
+	normalizeChecksums      bool
+	installedBy             string
+	baselineVersion         string
+	baselineDescription     string
+	cleanEnabled            bool
+	showWarnings            bool
+	versionStrategy         string
+	connectRetries          int
+	createDatabase          bool
+
+	fs *flag.FlagSet
+}
+
+func (o *options) register(fs *flag.FlagSet) {
+
+	o.fs = fs
+
+	fs.StringVar(&o.configFile, "config", os.Getenv("GOFLYWAY_CONFIG_FILE"),
+		fmt.Sprintf("configuration file, default is the first of %s found (env GOFLYWAY_CONFIG_FILE)", strings.Join(goflyway.ConfigFiles, ", ")))
+	fs.StringVar(&o.dsn, "dsn", "", "database connection string (env GOFLYWAY_DSN)")
+	fs.StringVar(&o.driver, "driver", "", "database driver: postgres, mysql, mariadb, sqlserver, sqlite3 or cockroachdb (env GOFLYWAY_DRIVER)")
+	fs.StringVar(&o.location, "location", "", "location of migration scripts (env GOFLYWAY_LOCATION)")
+	fs.StringVar(&o.table, "table", "", "schema history table (env GOFLYWAY_TABLE)")
+	fs.StringVar(&o.schemas, "schemas", "", "comma separated schemas managed by GoFlyway (env GOFLYWAY_SCHEMAS)")
+	fs.StringVar(&o.defaultSchema, "default-schema", "", "schema of the schema history table (env GOFLYWAY_DEFAULT_SCHEMA)")
+	fs.BoolVar(&o.createSchemas, "create-schemas", false, "create the schemas when they do not exist (env GOFLYWAY_CREATE_SCHEMAS)")
+	fs.BoolVar(&o.outOfOrder, "out-of-order", false, "allow migrations to be run out of order (env GOFLYWAY_OUT_OF_ORDER)")
+	fs.BoolVar(&o.ignoreMissingMigrations, "ignore-missing-migrations", false, "ignore applied migrations missing locally (env GOFLYWAY_IGNORE_MISSING_MIGRATIONS)")
+	fs.BoolVar(&o.flywayCompatible, "flyway-compatible", false, "use the schema history table of Java Flyway (env GOFLYWAY_FLYWAY_COMPATIBLE)")
+	fs.StringVar(&o.checksumAlgorithm, "checksum-algorithm", "", "checksum algorithm: sha256 or crc32 (env GOFLYWAY_CHECKSUM_ALGORITHM)")
+	fs.BoolVar(&o.normalizeChecksums, "normalize-checksums", false, "ignore CRLF line endings and byte order mark in checksums (env GOFLYWAY_NORMALIZE_CHECKSUMS)")
+	fs.StringVar(&o.installedBy, "installed-by", "", "user recorded in the schema history table, defaults to the CI user or the database user (env GOFLYWAY_INSTALLED_BY)")
+	fs.StringVar(&o.baselineVersion, "baseline-version", "", "version recorded by baseline (env GOFLYWAY_BASELINE_VERSION)")
+	fs.StringVar(&o.baselineDescription, "baseline-description", "", "description recorded by baseline (env GOFLYWAY_BASELINE_DESCRIPTION)")
+	fs.BoolVar(&o.cleanEnabled, "clean-enabled", false, "allow clean to drop all objects of the schemas (env GOFLYWAY_CLEAN_ENABLED)")
+	fs.StringVar(&o.versionStrategy, "version-strategy", "", "version picked by new: increment or timestamp (env GOFLYWAY_VERSION_STRATEGY)")
+	fs.BoolVar(&o.createDatabase, "create-database", false, "create the database of the DSN when it does not exist (env GOFLYWAY_CREATE_DATABASE)")
+	fs.IntVar(&o.connectRetries, "connect-retries", 0, "retries when connecting to the database fails, waiting up to 120s between them (env GOFLYWAY_CONNECT_RETRIES)")
+	fs.BoolVar(&o.showWarnings, "show-warnings", false, "show warning logs (env GOFLYWAY_SHOW_WARNING_LOG)")
+}
+
+// config Build the GoFlyway configuration, the database is opened by the caller. Driver and DSN are only required
+// by commands connecting to the database
+func (o *options) config(database bool) (goflyway.GoFlywayConfig, error) {
+
+	c, err := goflyway.LoadConfig(o.configFile)
+	if err != nil {
+		return c, err
+	}
+
+	c.InstalledByResolver = goflyway.InstalledByFromCI
+
+	o.fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "dsn":
+			c.DSN = o.dsn
+		case "driver":
+			c.Driver = goflyway.Driver(o.driver)
+		case "location":
+			c.Location = o.location
+		case "table":
+			c.Table = o.table
+		case "schemas":
+			c.Schemas = splitList(o.schemas)
+		case "default-schema":
+			c.DefaultSchema = o.defaultSchema
+		case "create-schemas":
+			c.CreateSchemas = o.createSchemas
+		case "out-of-order":
+			c.OutOfOrder = o.outOfOrder
+		case "ignore-missing-migrations":
+			c.IgnoreMissingMigrations = o.ignoreMissingMigrations
+		case "flyway-compatible":
+			c.FlywayCompatible = o.flywayCompatible
+		case "checksum-algorithm":
+			c.ChecksumAlgorithm = goflyway.ChecksumAlgorithm(o.checksumAlgorithm)
+		case "normalize-checksums":
+			c.NormalizeChecksums = o.normalizeChecksums
+		case "installed-by":
+			c.InstalledBy = o.installedBy
+		case "baseline-version":
+			c.BaselineVersion = o.baselineVersion
+		case "baseline-description":
+			c.BaselineDescription = o.baselineDescription
+		case "clean-enabled":
+			c.CleanEnabled = o.cleanEnabled
+		case "create-database":
+			c.CreateDatabase = o.createDatabase
+		case "connect-retries":
+			c.ConnectRetries = o.connectRetries
+		case "show-warnings":
+			c.ShowWarningLog = o.showWarnings
+		case "version-strategy":
+			c.VersionStrategy = goflyway.VersionStrategy(o.versionStrategy)
+		}
+	})
+
+	switch {
+	case database && len(c.Driver) <= 0:
+		return c, fmt.Errorf("missing -driver or GOFLYWAY_DRIVER")
+	case database && len(c.DSN) <= 0:
+		return c, fmt.Errorf("missing -dsn or GOFLYWAY_DSN")
+	case len(c.Location) <= 0:
+		return c, fmt.Errorf("missing -location or GOFLYWAY_LOCATION")
+	}
+
+	return c, nil
+}
+
+func splitList(value string) []string {
+
+	list := []string{}
+	for _, s := range strings.Split(value, ",") {
+		if s = strings.TrimSpace(s); len(s) > 0 {
+			list = append(list, s)
+		}
+	}
+
+	return list
+}
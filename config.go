@@ -0,0 +1,502 @@
+package goflyway
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFiles are the configuration files LoadConfig looks for in the working directory, in this order
+var ConfigFiles = []string{"goflyway.yaml", "goflyway.yml", "goflyway.toml", "goflyway.conf"}
+
+// envPrefix prefixes the environment variables read by LoadConfig, such as GOFLYWAY_OUT_OF_ORDER
+const envPrefix = "GOFLYWAY_"
+
+// ConfigError is returned by LoadConfig when a key has an invalid value or is unknown
+type ConfigError struct {
+	// Key as written in the configuration file or the environment variable name
+	Key string
+
+	// Configuration file or "environment"
+	Source string
+
+	Err error
+}
+
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("invalid configuration %s in %s: %v", e.Key, e.Source, e.Err)
+}
+
+func (e *ConfigError) Is(target error) bool {
+	return target == ErrInvalidConfig
+}
+
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
+// configKey applies a configuration value to GoFlywayConfig
+type configKey func(c *GoFlywayConfig, value interface{}) error
+
+// configKeys Settings read by LoadConfig, by normalized key. Keys are matched ignoring case, "_", "-" and ".",
+// so outOfOrder, out_of_order and GOFLYWAY_OUT_OF_ORDER are the same key
+var configKeys = map[string]configKey{
+	"table":                          stringKey(func(c *GoFlywayConfig, v string) { c.Table = v }),
+	"sqlmigrationprefix":             stringKey(func(c *GoFlywayConfig, v string) { c.SqlMigrationPrefix = v }),
+	"sqlmigrationseparator":          stringKey(func(c *GoFlywayConfig, v string) { c.SqlMigrationSeparator = v }),
+	"schemas":                        listKey(func(c *GoFlywayConfig, v []string) { c.Schemas = v }),
+	"defaultschema":                  stringKey(func(c *GoFlywayConfig, v string) { c.DefaultSchema = v }),
+	"createschemas":                  boolKey(func(c *GoFlywayConfig, v bool) { c.CreateSchemas = v }),
+	"location":                       stringKey(func(c *GoFlywayConfig, v string) { c.Location = v }),
+	"locations":                      locationsKey,
+	"outoforder":                     boolKey(func(c *GoFlywayConfig, v bool) { c.OutOfOrder = v }),
+	"ignoremissingmigrations":        boolKey(func(c *GoFlywayConfig, v bool) { c.IgnoreMissingMigrations = v }),
+	"driver":                         stringKey(func(c *GoFlywayConfig, v string) { c.Driver = Driver(v) }),
+	"sqldriver":                      stringKey(func(c *GoFlywayConfig, v string) { c.SqlDriver = v }),
+	"createdatabase":                 boolKey(func(c *GoFlywayConfig, v bool) { c.CreateDatabase = v }),
+	"databaseencoding":               stringKey(func(c *GoFlywayConfig, v string) { c.DatabaseEncoding = v }),
+	"databasecollation":              stringKey(func(c *GoFlywayConfig, v string) { c.DatabaseCollation = v }),
+	"connectretries":                 intKey(func(c *GoFlywayConfig, v int) { c.ConnectRetries = v }),
+	"connectretriesinterval":         durationKey(func(c *GoFlywayConfig, v time.Duration) { c.ConnectRetriesInterval = v }),
+	"dsn":                            stringKey(func(c *GoFlywayConfig, v string) { c.DSN = v }),
+	"initsql":                        stringKey(func(c *GoFlywayConfig, v string) { c.InitSQL = v }),
+	"scripttimeout":                  durationKey(func(c *GoFlywayConfig, v time.Duration) { c.ScriptTimeout = v }),
+	"locktimeout":                    durationKey(func(c *GoFlywayConfig, v time.Duration) { c.LockTimeout = v }),
+	"statementtimeout":               durationKey(func(c *GoFlywayConfig, v time.Duration) { c.StatementTimeout = v }),
+	"stalelockage":                   durationKey(func(c *GoFlywayConfig, v time.Duration) { c.StaleLockAge = v }),
+	"scriptretries":                  intKey(func(c *GoFlywayConfig, v int) { c.ScriptRetries = v }),
+	"scriptretriesinterval":          durationKey(func(c *GoFlywayConfig, v time.Duration) { c.ScriptRetriesInterval = v }),
+	"installedby":                    stringKey(func(c *GoFlywayConfig, v string) { c.InstalledBy = v }),
+	"flywaycompatible":               boolKey(func(c *GoFlywayConfig, v bool) { c.FlywayCompatible = v }),
+	"checksumalgorithm":              checksumAlgorithmKey,
+	"normalizechecksums":             boolKey(func(c *GoFlywayConfig, v bool) { c.NormalizeChecksums = v }),
+	"checksumtrimtrailingwhitespace": boolKey(func(c *GoFlywayConfig, v bool) { c.ChecksumTrimTrailingWhitespace = v }),
+	"showwarninglog":                 boolKey(func(c *GoFlywayConfig, v bool) { c.ShowWarningLog = v }),
+	"cleanenabled":                   boolKey(func(c *GoFlywayConfig, v bool) { c.CleanEnabled = v }),
+	"baselineversion":                stringKey(func(c *GoFlywayConfig, v string) { c.BaselineVersion = v }),
+	"baselinedescription":            stringKey(func(c *GoFlywayConfig, v string) { c.BaselineDescription = v }),
+	"placeholders":                   placeholdersKey,
+	"lintrules":                      lintRulesKey,
+	"versionstrategy":                versionStrategyKey,
+}
+
+// LoadConfig builds a GoFlywayConfig from a configuration file overridden by GOFLYWAY_* environment variables.
+// The file format follows its extension: .yaml or .yml, .toml, or .conf with key=value lines. When path is empty the
+// first of ConfigFiles found in the working directory is read, if any. Db is left to the caller, or opened from DSN.
+// Invalid or unknown keys are reported as *ConfigError
+func LoadConfig(path string) (GoFlywayConfig, error) {
+
+	c := GoFlywayConfig{}
+
+	if len(path) <= 0 {
+		for _, f := range ConfigFiles {
+			if _, err := os.Stat(f); err == nil {
+				path = f
+				break
+			}
+		}
+	}
+
+	if len(path) > 0 {
+		values, err := readConfigFile(path)
+		if err != nil {
+			return c, err
+		}
+
+		if err = applyConfigValues(&c, values, path); err != nil {
+			return c, err
+		}
+	}
+
+	if err := applyConfigValues(&c, readConfigEnv(os.Environ()), "environment"); err != nil {
+		return c, err
+	}
+
+	return c, nil
+}
+
+// readConfigFile Parse a configuration file into its top level keys
+func readConfigFile(path string) (map[string]interface{}, error) {
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading configuration file: %w", err)
+	}
+
+	values := map[string]interface{}{}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(b, &values)
+	case ".toml":
+		err = toml.Unmarshal(b, &values)
+	case ".conf":
+		values, err = parseConfFile(b)
+	default:
+		return nil, fmt.Errorf("%w: unsupported configuration file format %s", ErrInvalidConfig, path)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidConfig, path, err)
+	}
+
+	return values, nil
+}
+
+// parseConfFile Parse key=value lines, "#" starts a comment line. Keys may be prefixed with "goflyway.",
+// placeholders are written as placeholders.name=value and lint rules as lintRules.rule=severity
+func parseConfFile(b []byte) (map[string]interface{}, error) {
+
+	values := map[string]interface{}{}
+	placeholders := map[string]interface{}{}
+	lintRules := map[string]interface{}{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for line := 1; scanner.Scan(); line++ {
+
+		text := strings.TrimSpace(scanner.Text())
+		if len(text) <= 0 || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(text, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key=value", line)
+		}
+
+		key = strings.TrimPrefix(strings.TrimSpace(key), "goflyway.")
+		value = strings.TrimSpace(value)
+
+		if strings.HasPrefix(key, "placeholders.") {
+			placeholders[strings.TrimPrefix(key, "placeholders.")] = value
+			continue
+		}
+
+		if strings.HasPrefix(key, "lintRules.") {
+			lintRules[strings.TrimPrefix(key, "lintRules.")] = value
+			continue
+		}
+
+		values[key] = value
+	}
+
+	if len(placeholders) > 0 {
+		values["placeholders"] = placeholders
+	}
+
+	if len(lintRules) > 0 {
+		values["lintRules"] = lintRules
+	}
+
+	return values, scanner.Err()
+}
+
+// readConfigEnv Collect the GOFLYWAY_* variables. GOFLYWAY_PLACEHOLDERS_<NAME> sets the placeholder <name> in lower case
+// and GOFLYWAY_LINT_RULES_<RULE> the severity of a lint rule
+func readConfigEnv(environ []string) map[string]interface{} {
+
+	values := map[string]interface{}{}
+	placeholders := map[string]interface{}{}
+	lintRules := map[string]interface{}{}
+
+	for _, env := range environ {
+
+		key, value, _ := strings.Cut(env, "=")
+
+		if !strings.HasPrefix(key, envPrefix) {
+			continue
+		}
+		name := strings.TrimPrefix(key, envPrefix)
+
+		if strings.HasPrefix(name, "PLACEHOLDERS_") {
+			placeholders[strings.ToLower(strings.TrimPrefix(name, "PLACEHOLDERS_"))] = value
+			continue
+		}
+
+		if strings.HasPrefix(name, "LINT_RULES_") {
+			lintRules[strings.TrimPrefix(name, "LINT_RULES_")] = value
+			continue
+		}
+
+		// only the variables matching a setting are read, others such as GOFLYWAY_CONFIG_FILE belong to callers
+		if _, ok := configKeys[normalizeConfigKey(name)]; ok {
+			values[key] = value
+		}
+	}
+
+	if len(placeholders) > 0 {
+		values[envPrefix+"PLACEHOLDERS"] = placeholders
+	}
+
+	if len(lintRules) > 0 {
+		values[envPrefix+"LINT_RULES"] = lintRules
+	}
+
+	return values
+}
+
+// applyConfigValues Apply the values in key order, so errors are reported deterministically
+func applyConfigValues(c *GoFlywayConfig, values map[string]interface{}, source string) error {
+
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+
+		apply, ok := configKeys[normalizeConfigKey(strings.TrimPrefix(k, envPrefix))]
+		if !ok {
+			return &ConfigError{Key: k, Source: source, Err: fmt.Errorf("unknown key")}
+		}
+
+		if err := apply(c, values[k]); err != nil {
+			return &ConfigError{Key: k, Source: source, Err: err}
+		}
+	}
+
+	return nil
+}
+
+func normalizeConfigKey(key string) string {
+	return strings.ToLower(strings.NewReplacer("_", "", "-", "", ".", "").Replace(key))
+}
+
+func stringKey(set func(c *GoFlywayConfig, v string)) configKey {
+	return func(c *GoFlywayConfig, value interface{}) error {
+
+		switch v := value.(type) {
+		case string:
+			set(c, v)
+		case int, int64, float64:
+			set(c, fmt.Sprint(v))
+		default:
+			return fmt.Errorf("expected a string but got %T", value)
+		}
+
+		return nil
+	}
+}
+
+func boolKey(set func(c *GoFlywayConfig, v bool)) configKey {
+	return func(c *GoFlywayConfig, value interface{}) error {
+
+		switch v := value.(type) {
+		case bool:
+			set(c, v)
+		case string:
+			b, err := strconv.ParseBool(strings.TrimSpace(v))
+			if err != nil {
+				return fmt.Errorf("expected true or false but got %q", v)
+			}
+			set(c, b)
+		default:
+			return fmt.Errorf("expected true or false but got %T", value)
+		}
+
+		return nil
+	}
+}
+
+func intKey(set func(c *GoFlywayConfig, v int)) configKey {
+	return func(c *GoFlywayConfig, value interface{}) error {
+
+		switch v := value.(type) {
+		case int:
+			set(c, v)
+		case int64:
+			set(c, int(v))
+		case string:
+			i, err := strconv.Atoi(strings.TrimSpace(v))
+			if err != nil {
+				return fmt.Errorf("expected a number but got %q", v)
+			}
+			set(c, i)
+		default:
+			return fmt.Errorf("expected a number but got %T", value)
+		}
+
+		return nil
+	}
+}
+
+// durationKey accepts a duration such as "30s", or a number of seconds like Flyway
+func durationKey(set func(c *GoFlywayConfig, v time.Duration)) configKey {
+	return func(c *GoFlywayConfig, value interface{}) error {
+
+		switch v := value.(type) {
+		case int:
+			set(c, time.Duration(v)*time.Second)
+		case int64:
+			set(c, time.Duration(v)*time.Second)
+		case string:
+			v = strings.TrimSpace(v)
+			if seconds, err := strconv.Atoi(v); err == nil {
+				set(c, time.Duration(seconds)*time.Second)
+				return nil
+			}
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				return fmt.Errorf("expected a duration but got %q", v)
+			}
+			set(c, d)
+		default:
+			return fmt.Errorf("expected a duration but got %T", value)
+		}
+
+		return nil
+	}
+}
+
+// listKey accepts a list or a comma separated string
+func listKey(set func(c *GoFlywayConfig, v []string)) configKey {
+	return func(c *GoFlywayConfig, value interface{}) error {
+
+		list := []string{}
+
+		switch v := value.(type) {
+		case string:
+			for _, s := range strings.Split(v, ",") {
+				if s = strings.TrimSpace(s); len(s) > 0 {
+					list = append(list, s)
+				}
+			}
+		case []interface{}:
+			for _, item := range v {
+				s, ok := item.(string)
+				if !ok {
+					return fmt.Errorf("expected a list of strings but got %T", item)
+				}
+				list = append(list, s)
+			}
+		default:
+			return fmt.Errorf("expected a list but got %T", value)
+		}
+
+		set(c, list)
+
+		return nil
+	}
+}
+
+// locationsKey accepts the Flyway "locations" setting with a single location, GoFlyway reads scripts from one directory
+func locationsKey(c *GoFlywayConfig, value interface{}) error {
+
+	var locations []string
+	if err := listKey(func(c *GoFlywayConfig, v []string) { locations = v })(c, value); err != nil {
+		return err
+	}
+
+	if len(locations) != 1 {
+		return fmt.Errorf("expected a single location, GoFlyway reads scripts from one directory, but got %d: %s",
+			len(locations), strings.Join(locations, ", "))
+	}
+
+	c.Location = strings.TrimPrefix(locations[0], "filesystem:")
+
+	return nil
+}
+
+func checksumAlgorithmKey(c *GoFlywayConfig, value interface{}) error {
+
+	s, ok := value.(string)
+	if !ok {
+		return fmt.Errorf("expected a string but got %T", value)
+	}
+
+	switch a := ChecksumAlgorithm(strings.ToLower(s)); a {
+	case ChecksumSHA256, ChecksumCRC32:
+		c.ChecksumAlgorithm = a
+	default:
+		return fmt.Errorf("%w: %s", ErrUnsupportedChecksumAlgorithm, s)
+	}
+
+	return nil
+}
+
+func versionStrategyKey(c *GoFlywayConfig, value interface{}) error {
+
+	s, ok := value.(string)
+	if !ok {
+		return fmt.Errorf("expected a string but got %T", value)
+	}
+
+	switch v := VersionStrategy(strings.ToLower(s)); v {
+	case VersionIncrement, VersionTimestamp:
+		c.VersionStrategy = v
+	default:
+		return fmt.Errorf("expected %s or %s but got %q", VersionIncrement, VersionTimestamp, s)
+	}
+
+	return nil
+}
+
+// placeholdersKey merges the placeholders, so environment variables add to the ones of the configuration file
+func placeholdersKey(c *GoFlywayConfig, value interface{}) error {
+
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("expected a map of placeholder values but got %T", value)
+	}
+
+	if c.Placeholders == nil {
+		c.Placeholders = map[string]string{}
+	}
+
+	for k, v := range m {
+		switch v.(type) {
+		case string, bool, int, int64, float64:
+			c.Placeholders[k] = fmt.Sprint(v)
+		default:
+			return fmt.Errorf("expected a value for placeholder %s but got %T", k, v)
+		}
+	}
+
+	return nil
+}
+
+// lintRulesKey merges the severities of the lint rules. Rule names may use underscores, as they do in environment
+// variables: GOFLYWAY_LINT_RULES_DROP_COLUMN=warning
+func lintRulesKey(c *GoFlywayConfig, value interface{}) error {
+
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("expected a map of lint rule severities but got %T", value)
+	}
+
+	if c.LintRules == nil {
+		c.LintRules = map[LintRule]LintSeverity{}
+	}
+
+	for k, v := range m {
+
+		rule := LintRule(strings.ReplaceAll(strings.ToLower(k), "_", "-"))
+		if _, ok := lintDefaults[rule]; !ok {
+			return fmt.Errorf("unknown lint rule %s", k)
+		}
+
+		s, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("expected a severity for lint rule %s but got %T", k, v)
+		}
+
+		switch severity := LintSeverity(strings.ToLower(s)); severity {
+		case LintOff, LintWarning, LintError:
+			c.LintRules[rule] = severity
+		default:
+			return fmt.Errorf("unknown severity %s of lint rule %s", s, k)
+		}
+	}
+
+	return nil
+}
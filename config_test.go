@@ -0,0 +1,186 @@
+package goflyway
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func writeConfigFile(t *testing.T, name string, content string) string {
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("errors happened when writing configuration file: %v", err)
+	}
+
+	return path
+}
+
+func TestLoadConfigFormats(t *testing.T) {
+
+	files := map[string]string{
+		"goflyway.yaml": `
+table: history
+outOfOrder: true
+schemas: [app, audit]
+locations: ["filesystem:db/migration"]
+driver: postgres
+dsn: postgres://localhost/app
+connectRetries: 5
+connectRetriesInterval: 30s
+lockTimeout: 5s
+scriptRetries: 3
+placeholders:
+  owner: admin
+lintRules:
+  drop-column: warning
+`,
+		"goflyway.toml": `
+table = "history"
+out_of_order = true
+schemas = ["app", "audit"]
+locations = ["filesystem:db/migration"]
+driver = "postgres"
+dsn = "postgres://localhost/app"
+connect_retries = 5
+connect_retries_interval = "30s"
+lock_timeout = "5s"
+script_retries = 3
+
+[placeholders]
+owner = "admin"
+
+[lint_rules]
+drop_column = "warning"
+`,
+		"goflyway.conf": `
+# Flyway style properties
+goflyway.table=history
+goflyway.outOfOrder=true
+goflyway.schemas=app, audit
+goflyway.location=db/migration
+goflyway.driver=postgres
+goflyway.dsn=postgres://localhost/app
+goflyway.connectRetries=5
+goflyway.connectRetriesInterval=30
+goflyway.lockTimeout=5
+goflyway.scriptRetries=3
+goflyway.placeholders.owner=admin
+goflyway.lintRules.drop-column=warning
+`,
+	}
+
+	expected := GoFlywayConfig{
+		Table:        "history",
+		OutOfOrder:   true,
+		Schemas:      []string{"app", "audit"},
+		Location:     "db/migration",
+		Driver:       POSTGRES,
+		DSN:          "postgres://localhost/app",
+		Placeholders: map[string]string{"owner": "admin"},
+		LintRules:    map[LintRule]LintSeverity{LintDropColumn: LintWarning},
+
+		ConnectRetries:         5,
+		ConnectRetriesInterval: 30 * time.Second,
+		LockTimeout:            5 * time.Second,
+		ScriptRetries:          3,
+	}
+
+	for name, content := range files {
+
+		c, err := LoadConfig(writeConfigFile(t, name, content))
+		if err != nil {
+			t.Fatalf("%s: expected nil but got error %v", name, err)
+		}
+
+		if !reflect.DeepEqual(c, expected) {
+			t.Errorf("%s: expected %+v but got %+v", name, expected, c)
+		}
+	}
+}
+
+func TestLoadConfigEnvironment(t *testing.T) {
+
+	path := writeConfigFile(t, "goflyway.yaml", "table: history\nsqlMigrationPrefix: M\nplaceholders:\n  owner: admin\n")
+
+	t.Setenv("GOFLYWAY_TABLE", "env_history")
+	t.Setenv("GOFLYWAY_CREATE_SCHEMAS", "true")
+	t.Setenv("GOFLYWAY_PLACEHOLDERS_SCHEMA", "app")
+	t.Setenv("GOFLYWAY_INSTALLED_BY", "ci")
+	t.Setenv("GOFLYWAY_LINT_RULES_MISSING_WHERE", "off")
+
+	c, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	if c.Table != "env_history" || c.SqlMigrationPrefix != "M" || !c.CreateSchemas || c.InstalledBy != "ci" {
+		t.Errorf("expected environment to override the configuration file but got %+v", c)
+	}
+
+	if !reflect.DeepEqual(c.Placeholders, map[string]string{"owner": "admin", "schema": "app"}) {
+		t.Errorf("expected merged placeholders but got %v", c.Placeholders)
+	}
+
+	if !reflect.DeepEqual(c.LintRules, map[LintRule]LintSeverity{LintMissingWhere: LintOff}) {
+		t.Errorf("expected lint rule severity from the environment but got %v", c.LintRules)
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		env     string
+		key     string
+	}{
+		{"invalid bool", "goflyway.yaml", "outOfOrder: sometimes\n", "", "outOfOrder"},
+		{"unknown key", "goflyway.toml", "tabel = \"history\"\n", "", "tabel"},
+		{"multiple locations", "goflyway.yaml", "locations: [a, b]\n", "", "locations"},
+		{"comma separated locations", "goflyway.conf", "locations=filesystem:a,filesystem:b\n", "", "locations"},
+		{"invalid checksum algorithm", "goflyway.conf", "checksumAlgorithm=md5\n", "", "checksumAlgorithm"},
+		{"invalid environment", "goflyway.conf", "table=history\n", "GOFLYWAY_CLEAN_ENABLED", "GOFLYWAY_CLEAN_ENABLED"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			if len(tt.env) > 0 {
+				t.Setenv(tt.env, "maybe")
+			}
+
+			_, err := LoadConfig(writeConfigFile(t, tt.file, tt.content))
+
+			var configErr *ConfigError
+			if !errors.As(err, &configErr) || !errors.Is(err, ErrInvalidConfig) {
+				t.Fatalf("expected ConfigError but got %v", err)
+			}
+
+			if configErr.Key != tt.key {
+				t.Errorf("expected error naming key %s but got %s", tt.key, configErr.Key)
+			}
+		})
+	}
+
+	_, err := LoadConfig(writeConfigFile(t, "goflyway.json", "{}"))
+	if !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("expected ErrInvalidConfig for unsupported format but got %v", err)
+	}
+}
+
+func TestReplacePlaceholders(t *testing.T) {
+
+	script := "CREATE TABLE ${schema}.product (id INT); GRANT SELECT ON ${schema}.product TO ${reader};"
+
+	got := replacePlaceholders(script, map[string]string{"schema": "app"})
+	expected := "CREATE TABLE app.product (id INT); GRANT SELECT ON app.product TO ${reader};"
+
+	if got != expected {
+		t.Errorf("expected %s but got %s", expected, got)
+	}
+}
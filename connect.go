@@ -0,0 +1,180 @@
+package goflyway
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+)
+
+// connectRetriesIntervalDefault is the longest wait between connection attempts when ConnectRetriesInterval is not set
+const connectRetriesIntervalDefault = 120 * time.Second
+
+// connectFirstInterval is the wait before the first retry, doubled after each failed attempt
+const connectFirstInterval = time.Second
+
+// defaultSqlDrivers database/sql driver used to open the DSN of each built-in driver when SqlDriver is not set
+var defaultSqlDrivers = map[Driver]string{
+	POSTGRES:    "pgx",
+	COCKROACHDB: "pgx",
+	MYSQL:       "mysql",
+	MARIADB:     "mysql",
+	MSSQLSERVER: "sqlserver",
+	SQLITE3:     "sqlite",
+}
+
+// Open opens the database of c.DSN with the database/sql driver c.SqlDriver, or the default one of c.Driver, and waits
+// until it accepts connections. Failed attempts are retried c.ConnectRetries times with a wait starting at one second
+// and doubled up to c.ConnectRetriesInterval. The database/sql driver must be imported by the caller
+func Open(c GoFlywayConfig) (*sql.DB, error) {
+
+	if c.Logger == nil {
+		c.Logger = log.Default()
+	}
+
+	return openDatabase(context.Background(), c)
+}
+
+// connect Open the database of DSN when Db is not set and returns the function that closes it
+func (g *goFlywayRunner) connect() (func(), error) {
+
+	if g.config.Db != nil {
+		return func() {}, nil
+	}
+
+	if len(g.config.DSN) <= 0 {
+		return nil, ErrDatabaseConnectionNull
+	}
+
+	db, err := openDatabase(context.Background(), g.config)
+	if err != nil {
+		return nil, throwErrMigration(err)
+	}
+	g.config.Db = db
+
+	return func() {
+		db.Close()
+	}, nil
+}
+
+func openDatabase(ctx context.Context, c GoFlywayConfig) (*sql.DB, error) {
+
+	sqlDriver := c.SqlDriver
+	if len(sqlDriver) <= 0 {
+		sqlDriver = defaultSqlDrivers[c.Driver]
+	}
+
+	if len(sqlDriver) <= 0 {
+		return nil, fmt.Errorf("%w: %s has no default database/sql driver, set SqlDriver", ErrUnsupportedDatabaseDriver, c.Driver)
+	}
+
+	db, err := sql.Open(sqlDriver, c.DSN)
+	if err != nil {
+		return nil, &ConnectionError{Err: err}
+	}
+
+	maxInterval := c.ConnectRetriesInterval
+	if maxInterval <= 0 {
+		maxInterval = connectRetriesIntervalDefault
+	}
+
+	interval := minDuration(connectFirstInterval, maxInterval)
+
+	for attempt := 1; ; attempt++ {
+
+		err = db.PingContext(ctx)
+		if err == nil {
+			return db, nil
+		}
+
+		if attempt > c.ConnectRetries {
+			break
+		}
+
+		c.Logger.Printf("connection attempt %d of %d failed, retrying in %s: %v", attempt, c.ConnectRetries+1, interval, err)
+
+		time.Sleep(interval)
+		interval = minDuration(interval*2, maxInterval)
+	}
+
+	db.Close()
+
+	return nil, &ConnectionError{Err: err}
+}
+
+// createDatabase Create the database of DSN when CreateDatabase is set and it does not exist, through the maintenance
+// database of the server
+func (g *goFlywayRunner) createDatabase() error {
+
+	if !g.config.CreateDatabase {
+		return nil
+	}
+
+	fail := func(err error) error {
+		return throwErrMigration(fmt.Errorf("error creating database: %w", err))
+	}
+
+	d, ok := g.dialect.(DatabaseCreator)
+	if !ok {
+		return fail(fmt.Errorf("%w by database driver %s", ErrCreateDatabaseNotSupported, g.config.Driver))
+	}
+
+	if g.config.Db != nil || len(g.config.DSN) <= 0 {
+		return fail(fmt.Errorf("%w: the database is created from DSN, Db must not be set", ErrCreateDatabaseNotSupported))
+	}
+
+	for _, option := range []string{g.config.DatabaseEncoding, g.config.DatabaseCollation} {
+		if len(option) > 0 && !regexDatabaseOption.MatchString(option) {
+			return fail(fmt.Errorf("invalid encoding or collation %q", option))
+		}
+	}
+
+	maintenance, database, err := d.MaintenanceDSN(g.config.DSN)
+	if err != nil {
+		return fail(err)
+	}
+
+	command, err := d.CreateDatabase(database, g.config.DatabaseEncoding, g.config.DatabaseCollation)
+	if err != nil {
+		return fail(err)
+	}
+
+	c := g.config
+	c.DSN = maintenance
+
+	ctx := context.Background()
+
+	db, err := openDatabase(ctx, c)
+	if err != nil {
+		return fail(err)
+	}
+	defer db.Close()
+
+	exists := func() (bool, error) {
+		var total int
+		err := queryRow(ctx, db, d.DatabaseExists(), g.historyArgs(sql.Named("database", database)), &total)
+		return total > 0, err
+	}
+
+	found, err := exists()
+	if err != nil {
+		return fail(err)
+	}
+
+	if found {
+		return nil
+	}
+
+	if _, err = db.ExecContext(ctx, command); err != nil {
+		// another run may have created it meanwhile
+		if found, errExists := exists(); errExists != nil || !found {
+			return fail(err)
+		}
+		return nil
+	}
+
+	g.config.Logger.Printf("successfully created database %s", database)
+
+	return nil
+}
@@ -0,0 +1,142 @@
+package goflyway
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// flakyDriver refuses the first connections to each DSN, as a database still starting
+type flakyDriver struct {
+	mu       sync.Mutex
+	refusals map[string]int
+	f        *fakeExecutor
+}
+
+var flaky = &flakyDriver{refusals: map[string]int{}, f: newFakeExecutor()}
+
+var errConnectionRefused = errors.New("dial tcp: connection refused")
+
+func init() {
+	sql.Register("goflyway-flaky", flaky)
+}
+
+func (d *flakyDriver) Open(name string) (driver.Conn, error) {
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.refusals[name] > 0 {
+		d.refusals[name]--
+		return nil, errConnectionRefused
+	}
+
+	return &fakeConn{d.f}, nil
+}
+
+func (d *flakyDriver) refuse(name string, times int) {
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.refusals[name] = times
+}
+
+func TestMigrateDSNConnectRetries(t *testing.T) {
+
+	conf := getFakeConfig(nil)
+	conf.Db = nil
+	conf.DSN = t.Name()
+	conf.SqlDriver = "goflyway-flaky"
+	conf.ConnectRetries = 3
+	conf.ConnectRetriesInterval = time.Millisecond
+
+	flaky.refuse(conf.DSN, 3)
+
+	result, err := Migrate(conf)
+	if err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	if result.MigrationsExecuted != 3 {
+		t.Errorf("expected 3 migrations but got %d", result.MigrationsExecuted)
+	}
+
+	flaky.refuse(conf.DSN, 4)
+
+	_, err = Migrate(conf)
+	if !errors.Is(err, ErrDatabaseConnection) {
+		t.Errorf("expected error %v but got %v", ErrDatabaseConnection, err)
+	}
+
+	// the driver error is kept
+	var connErr *ConnectionError
+	if !errors.As(err, &connErr) || !errors.Is(err, errConnectionRefused) {
+		t.Errorf("expected ConnectionError wrapping %v but got %v", errConnectionRefused, err)
+	}
+}
+
+func TestOpen_UnknownSqlDriver(t *testing.T) {
+
+	_, err := Open(GoFlywayConfig{Driver: "oracle", DSN: "x"})
+	if !errors.Is(err, ErrUnsupportedDatabaseDriver) {
+		t.Errorf("expected error %v but got %v", ErrUnsupportedDatabaseDriver, err)
+	}
+}
+
+// creatorDialect creates databases on the fake executor of the flaky driver
+type creatorDialect struct {
+	sqlite3Dialect
+}
+
+func (d *creatorDialect) MaintenanceDSN(dsn string) (string, string, error) {
+	return dsn + "-maintenance", dsn, nil
+}
+
+func (d *creatorDialect) DatabaseExists() string {
+	return "SELECT COUNT(*) FROM databases WHERE name = ?"
+}
+
+func (d *creatorDialect) CreateDatabase(database string, encoding string, collation string) (string, error) {
+	return fillDatabaseTemplate("CREATE DATABASE [databaseName]", d, database), nil
+}
+
+func TestMigrateCreateDatabase(t *testing.T) {
+
+	RegisterDialect("sqlite3-creator", &creatorDialect{})
+	flaky.f.onQuery("FROM databases", []string{"total"}, []driver.Value{int64(0)})
+
+	conf := getFakeConfig(nil)
+	conf.Db = nil
+	conf.Driver = "sqlite3-creator"
+	conf.DSN = t.Name()
+	conf.SqlDriver = "goflyway-flaky"
+	conf.CreateDatabase = true
+
+	if _, err := Migrate(conf); err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	if created := flaky.f.executedContaining(`CREATE DATABASE "` + t.Name() + `"`); len(created) != 1 {
+		t.Errorf("expected the database to be created once but got %q", created)
+	}
+
+	conf.DatabaseCollation = "en_US'; DROP TABLE users"
+
+	if _, err := Migrate(conf); err == nil {
+		t.Errorf("expected error for invalid collation")
+	}
+}
+
+func TestMigrateCreateDatabase_NotSupported(t *testing.T) {
+
+	conf := getFakeConfig(newFakeExecutor())
+	conf.CreateDatabase = true
+
+	if _, err := Migrate(conf); !errors.Is(err, ErrCreateDatabaseNotSupported) {
+		t.Errorf("expected error %v but got %v", ErrCreateDatabaseNotSupported, err)
+	}
+}
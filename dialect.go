@@ -0,0 +1,447 @@
+package goflyway
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"hash/crc32"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ParamStyle describes how the parameters of the history insert are bound
+type ParamStyle int
+
+const (
+	// Parameters are bound by position, in the order installed_rank, version, description, type, script, checksum,
+	// installed_by, execution_time
+	ParamPositional ParamStyle = iota
+
+	// Parameters are bound with sql.Named using the column names as parameter names
+	ParamNamed
+)
+
+// Dialect implements the database specific parts of GoFlyway.
+// Schema and table names are received unquoted, the dialect is responsible for quoting them
+type Dialect interface {
+	// QuoteIdentifier quotes a schema or table name
+	QuoteIdentifier(name string) string
+
+	// CreateSchema returns the command that creates the schema when it does not exist
+	CreateSchema(schema string) (string, error)
+
+	// CreateHistoryTable returns the command that creates the schema history table when it does not exist
+	CreateHistoryTable(schema string, table string) string
+
+	// SelectHistory returns the query that lists the schema history table columns installed_rank, version, description,
+	// type, script, checksum, installed_by, installed_on, execution_time and success, in this order
+	SelectHistory(schema string, table string) string
+
+	// InsertHistory returns the command that inserts a row into the schema history table, bound as described by ParamStyle
+	InsertHistory(schema string, table string) string
+
+	// ParamStyle returns how the InsertHistory parameters are bound
+	ParamStyle() ParamStyle
+
+	// SearchPath returns the command executed in the script transaction to resolve unqualified names against schemas.
+	// Empty when not supported
+	SearchPath(schemas []string) string
+
+	// Lock acquires an exclusive lock that prevents concurrent migrations on the schema history table.
+	// It blocks until the lock is acquired
+	Lock(ctx context.Context, conn *sql.Conn, schema string, table string) error
+
+	// Unlock releases the lock acquired by Lock
+	Unlock(ctx context.Context, conn *sql.Conn, schema string, table string) error
+
+	// SplitStatements splits a migration script into the statements executed one after another
+	SplitStatements(script string) []string
+
+	// Clean drops all objects of the given schemas. When schemas is empty the connection default schema is cleaned
+	Clean(ctx context.Context, conn *sql.Conn, schemas []string) error
+}
+
+// RetryableDialect is implemented by dialects whose script transactions can fail with errors that are expected to
+// succeed when the whole transaction is retried, such as deadlocks, lock timeouts and serialization conflicts
+type RetryableDialect interface {
+	IsRetryable(err error) bool
+}
+
+// DefaultRetryDialect is implemented by dialects whose databases expect clients to retry some errors, such as the
+// serialization conflicts of CockroachDB. DefaultRetries returns how many times a migration failing with err is retried
+// when ScriptRetries is not set
+type DefaultRetryDialect interface {
+	DefaultRetries(err error) int
+}
+
+// HistoryTableChecker is implemented by dialects that check whether the schema history table exists
+// before running CreateHistoryTable
+type HistoryTableChecker interface {
+	HistoryTableExists(ctx context.Context, db Executor, schema string, table string) (bool, error)
+}
+
+// TransactionalDDLDialect is implemented by dialects that report whether DDL statements take part in transactions.
+// Dialects that do not implement it are assumed to support transactional DDL
+type TransactionalDDLDialect interface {
+	TransactionalDDL() bool
+}
+
+// NonTransactionalDialect is implemented by dialects with statements that cannot run inside a transaction, such as
+// CREATE INDEX CONCURRENTLY on PostgreSQL. MigrateTx refuses the scripts holding one, and on dialects without
+// transactional DDL the scripts holding DDL statements
+type NonTransactionalDialect interface {
+	// NonTransactional reports whether statement, stripped of comments and with single spaces, cannot run in a transaction
+	NonTransactional(statement string) bool
+}
+
+// SearchPathRestorer is implemented by dialects whose SearchPath lasts until the end of the transaction. MigrateTx
+// restores the search path of the caller transaction once the scripts are applied
+type SearchPathRestorer interface {
+	// CurrentSearchPath returns the query that selects the search path in effect
+	CurrentSearchPath() string
+
+	// RestoreSearchPath returns the command that sets the search path bound as its only parameter until the end of
+	// the transaction
+	RestoreSearchPath() string
+}
+
+// staleLockAgeDefault is the age after which the lock of an ExpiringLockDialect is taken over
+const staleLockAgeDefault = time.Hour
+
+// ExpiringLockDialect is implemented by dialects whose migration lock is a row that outlives the session of a
+// crashed run. GoFlyway calls LockExpiring instead of Lock, bounding the wait with LockTimeout or StatementTimeout
+type ExpiringLockDialect interface {
+	// LockExpiring acquires the lock like Lock, taking over a lock acquired longer than staleAge ago
+	LockExpiring(ctx context.Context, conn *sql.Conn, schema string, table string, staleAge time.Duration) error
+}
+
+// LintDialect is implemented by dialects that take part in Lint with their statement syntax and rules. Lint splits
+// the scripts of other dialects on semicolons and checks them against every rule but LintNonConcurrentIndex
+type LintDialect interface {
+	// LintStatements splits a migration script into the statements checked by Lint, keeping their comments
+	LintStatements(script string) []string
+
+	// LintRules returns the rules that apply to the database
+	LintRules() []LintRule
+}
+
+// TimestampDialect is implemented by dialects whose drivers may return installed_on as text
+type TimestampDialect interface {
+	// TimestampLayouts returns the layouts tried, in order, to parse installed_on. Values without zone are UTC
+	TimestampLayouts() []string
+}
+
+// CurrentUserDialect is implemented by dialects that can tell the database user, stored as installed_by when
+// GoFlywayConfig does not set one
+type CurrentUserDialect interface {
+	// CurrentUser returns the query that selects the current database user
+	CurrentUser() string
+}
+
+// FlywayDialect is implemented by dialects that can create the schema history table with the layout of Java Flyway,
+// required by GoFlywayConfig.FlywayCompatible
+type FlywayDialect interface {
+	// CreateFlywayHistoryTable returns the commands that create the Flyway schema history table when it does not exist.
+	// They are executed after SplitStatements
+	CreateFlywayHistoryTable(schema string, table string) string
+}
+
+// HistoryUpgradeDialect is implemented by dialects that upgrade the layout of schema history tables created by previous
+// GoFlyway versions. The layout version is kept in a table named after the schema history table with the "_layout" suffix
+type HistoryUpgradeDialect interface {
+	// CreateLayoutTable returns the command that creates the layout table, with an INT layout_version column, when it does
+	// not exist
+	CreateLayoutTable(schema string, layoutTable string) string
+
+	// UpgradeHistoryTable returns the commands that upgrade the schema history table from layout version-1 to version
+	UpgradeHistoryTable(schema string, table string, version int) []string
+}
+
+// RepairDialect is implemented by dialects that can change applied rows of the schema history table, required by Repair
+type RepairDialect interface {
+	// UpdateHistory returns the command that sets the checksum and description of a row, bound as described by ParamStyle
+	// in the order checksum, description, installed_rank
+	UpdateHistory(schema string, table string) string
+
+	// DeleteHistory returns the command that deletes a row, bound as described by ParamStyle with the installed_rank
+	DeleteHistory(schema string, table string) string
+}
+
+// DatabaseCreator is implemented by dialects that create the database of the DSN through the maintenance database of
+// the server, required by GoFlywayConfig.CreateDatabase
+type DatabaseCreator interface {
+	// MaintenanceDSN returns dsn connected to the maintenance database of the server instead, and the database of dsn
+	MaintenanceDSN(dsn string) (maintenance string, database string, err error)
+
+	// DatabaseExists returns the query that counts the databases with the given name, bound as described by ParamStyle
+	// with the parameter database
+	DatabaseExists() string
+
+	// CreateDatabase returns the command that creates the database. Empty encoding and collation use the server defaults
+	CreateDatabase(database string, encoding string, collation string) (string, error)
+}
+
+// SessionTimeoutDialect is implemented by dialects that bound the lock waits and the statements of the migration
+// session, required by GoFlywayConfig.LockTimeout and StatementTimeout
+type SessionTimeoutDialect interface {
+	// SessionTimeouts returns the commands that set the timeouts of the session, zero timeouts are not set
+	SessionTimeouts(lockTimeout time.Duration, statementTimeout time.Duration) ([]string, error)
+
+	// ExceededTimeout returns the name of the session setting err reports as exceeded, and whether it bounds lock waits.
+	// The name is empty for other errors
+	ExceededTimeout(err error) (setting string, lock bool)
+}
+
+var (
+	dialectsMu sync.RWMutex
+	dialects   = map[Driver]Dialect{}
+)
+
+func init() {
+	RegisterDialect(POSTGRES, &postgresDialect{})
+	RegisterDialect(MYSQL, &mysqlDialect{})
+	RegisterDialect(MSSQLSERVER, &sqlServerDialect{})
+	RegisterDialect(SQLITE3, &sqlite3Dialect{})
+	RegisterDialect(COCKROACHDB, &cockroachDialect{})
+	RegisterDialect(MARIADB, &mariadbDialect{})
+}
+
+// RegisterDialect makes a dialect available by the provided driver name. Registering a name twice replaces the previous dialect.
+// It panics if dialect is nil
+func RegisterDialect(driver Driver, dialect Dialect) {
+
+	if dialect == nil {
+		panic("goflyway: RegisterDialect dialect is nil")
+	}
+
+	dialectsMu.Lock()
+	defer dialectsMu.Unlock()
+
+	dialects[driver] = dialect
+}
+
+// supportsTransactionalDDL Reports whether a failed script is fully rolled back by the dialect
+func supportsTransactionalDDL(d Dialect) bool {
+
+	t, ok := d.(TransactionalDDLDialect)
+	return !ok || t.TransactionalDDL()
+}
+
+// isNonTransactional Reports whether statement cannot run in the transaction of MigrateTx, either because the dialect
+// refuses it in a transaction or because it is DDL that the dialect commits implicitly
+func isNonTransactional(d Dialect, statement string) bool {
+
+	if n, ok := d.(NonTransactionalDialect); ok && n.NonTransactional(statement) {
+		return true
+	}
+
+	return !supportsTransactionalDDL(d) && regexDDLStatement.MatchString(statement)
+}
+
+func getDialect(driver Driver) (Dialect, error) {
+
+	dialectsMu.RLock()
+	defer dialectsMu.RUnlock()
+
+	d, ok := dialects[driver]
+	if !ok {
+		return nil, ErrUnsupportedDatabaseDriver
+	}
+
+	return d, nil
+}
+
+// fillTemplate Fill the table and schema placeholders of a command with quoted names
+func fillTemplate(command string, d Dialect, schema string, table string) string {
+
+	qualified := qualifiedTableName(d, schema, table)
+
+	command = regexTableNameLiteral.ReplaceAllLiteralString(command, quoteLiteral(qualified))
+	command = regexTableName.ReplaceAllLiteralString(command, qualified)
+	command = regexSchemaNameLiteral.ReplaceAllLiteralString(command, quoteLiteral(schema))
+
+	return regexSchemaName.ReplaceAllLiteralString(command, d.QuoteIdentifier(schema))
+}
+
+// fillDatabaseTemplate Fill a template creating or checking a database
+func fillDatabaseTemplate(command string, d Dialect, database string) string {
+	return regexDatabaseName.ReplaceAllLiteralString(command, d.QuoteIdentifier(database))
+}
+
+// fillHistoryTemplate Fill a schema history template, whose constraint names derive from the table name
+func fillHistoryTemplate(command string, d Dialect, schema string, table string) string {
+
+	command = regexPrimaryKeyName.ReplaceAllLiteralString(command, d.QuoteIdentifier(table+"_pk"))
+	command = regexSuccessIndexName.ReplaceAllLiteralString(command, d.QuoteIdentifier(table+"_s_idx"))
+
+	return fillTemplate(command, d, schema, table)
+}
+
+func qualifiedTableName(d Dialect, schema string, table string) string {
+
+	if len(schema) <= 0 {
+		return d.QuoteIdentifier(table)
+	}
+
+	return d.QuoteIdentifier(schema) + "." + d.QuoteIdentifier(table)
+}
+
+func quoteLiteral(value string) string {
+	return strings.ReplaceAll(value, "'", "''")
+}
+
+// lockKey Returns a stable lock identifier for the schema history table
+func lockKey(schema string, table string) int64 {
+	return int64(crc32.ChecksumIEEE([]byte(schema + "." + table)))
+}
+
+// execGeneratedStatements Run a query that returns one statement per row and execute each of them in order
+func execGeneratedStatements(ctx context.Context, conn *sql.Conn, query string, args ...interface{}) error {
+
+	rows, err := conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	statements := []string{}
+	for rows.Next() {
+		var s string
+		if err = rows.Scan(&s); err != nil {
+			rows.Close()
+			return err
+		}
+		statements = append(statements, s)
+	}
+	rows.Close()
+
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	for _, s := range statements {
+		if _, err = conn.ExecContext(ctx, s); err != nil {
+			return fmt.Errorf("%s: %w", s, err)
+		}
+	}
+
+	return nil
+}
+
+// splitOptions Lexical rules used by splitStatements
+type splitOptions struct {
+	// Whether '#' starts a line comment
+	hashComments bool
+
+	// Whether backticks quote identifiers
+	backticks bool
+
+	// Whether the client side DELIMITER command is understood
+	delimiterCommand bool
+
+	// Whether $$ and $tag$ quote strings, such as function bodies on PostgreSQL
+	dollarQuotes bool
+
+	// Whether a backslash escapes the next character inside quoted strings, as on MySQL
+	backslashEscapes bool
+}
+
+// splitStatements Split a script on the statement delimiter, ignoring delimiters inside quotes and comments.
+// Empty statements are dropped
+func splitStatements(script string, opts splitOptions) []string {
+
+	statements := []string{}
+	delimiter := ";"
+
+	var current strings.Builder
+	flush := func() {
+		s := strings.TrimSpace(current.String())
+		if len(s) > 0 {
+			statements = append(statements, s)
+		}
+		current.Reset()
+	}
+
+	atLineStart := true
+
+	for i := 0; i < len(script); {
+
+		if opts.delimiterCommand && atLineStart {
+			lineEnd := strings.IndexByte(script[i:], '\n')
+			if lineEnd < 0 {
+				lineEnd = len(script) - i
+			}
+			line := strings.TrimSpace(script[i : i+lineEnd])
+			if len(line) > 10 && strings.EqualFold(line[:10], "DELIMITER ") {
+				flush()
+				delimiter = strings.TrimSpace(line[10:])
+				i += lineEnd
+				continue
+			}
+		}
+
+		c := script[i]
+		atLineStart = c == '\n'
+
+		switch {
+		case c == '\'' || c == '"' || (c == '`' && opts.backticks):
+			end := i + 1
+			for end < len(script) {
+				if script[end] == '\\' && c != '`' && opts.backslashEscapes {
+					end += 2
+					continue
+				}
+				if script[end] == c {
+					if end+1 < len(script) && script[end+1] == c {
+						end += 2
+						continue
+					}
+					break
+				}
+				end++
+			}
+			if end >= len(script) {
+				end = len(script) - 1
+			}
+			current.WriteString(script[i : end+1])
+			i = end + 1
+
+		case c == '$' && opts.dollarQuotes && regexDollarQuote.MatchString(script[i:]):
+			tag := regexDollarQuote.FindString(script[i:])
+			end := strings.Index(script[i+len(tag):], tag)
+			if end < 0 {
+				end = len(script) - i - 2*len(tag)
+			}
+			current.WriteString(script[i : i+end+2*len(tag)])
+			i += end + 2*len(tag)
+
+		case strings.HasPrefix(script[i:], "--") || (c == '#' && opts.hashComments):
+			end := strings.IndexByte(script[i:], '\n')
+			if end < 0 {
+				end = len(script) - i
+			}
+			current.WriteString(script[i : i+end])
+			i += end
+
+		case strings.HasPrefix(script[i:], "/*"):
+			end := strings.Index(script[i+2:], "*/")
+			if end < 0 {
+				end = len(script) - i - 4
+			}
+			current.WriteString(script[i : i+end+4])
+			i += end + 4
+
+		case strings.HasPrefix(script[i:], delimiter):
+			flush()
+			i += len(delimiter)
+
+		default:
+			current.WriteByte(c)
+			i++
+		}
+	}
+
+	flush()
+
+	return statements
+}
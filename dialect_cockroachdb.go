@@ -0,0 +1,229 @@
+package goflyway
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// cockroachLockPollInterval is the time waited between attempts to take the lock row
+const cockroachLockPollInterval = time.Second
+
+// cockroachRetriesDefault is the number of retries of a serialization conflict when ScriptRetries is not set
+const cockroachRetriesDefault = 5
+
+// cockroachDialect talks to CockroachDB through the Postgres wire protocol. It keeps the history table DDL out of
+// explicit transactions, retries serializable transaction conflicts and replaces pg_advisory_lock, which CockroachDB
+// does not implement, with a lock table
+type cockroachDialect struct {
+	postgresDialect
+
+	mu     sync.Mutex
+	owners map[*sql.Conn]string
+}
+
+// MaintenanceDSN connects to defaultdb, the postgres database may have been dropped
+func (d *cockroachDialect) MaintenanceDSN(dsn string) (string, string, error) {
+	return replaceDSNDatabasePostgres(dsn, "defaultdb")
+}
+
+// CreateDatabase supports only the UTF8 encoding, collations are set per column
+func (d *cockroachDialect) CreateDatabase(database string, encoding string, collation string) (string, error) {
+
+	if len(collation) > 0 {
+		return "", fmt.Errorf("database collation is not supported by %s, collations are set per column", COCKROACHDB)
+	}
+
+	command := fillDatabaseTemplate(createDatabaseCockroachDb, d, database)
+
+	if len(encoding) > 0 {
+		command += fmt.Sprintf(" ENCODING = '%s'", quoteLiteral(encoding))
+	}
+
+	return command, nil
+}
+
+func (d *cockroachDialect) CreateHistoryTable(schema string, table string) string {
+	return fillHistoryTemplate(createTableCockroachDb, d, schema, table)
+}
+
+// UpgradeHistoryTable has nothing to upgrade to layout 2, constraint names are local to their table
+func (d *cockroachDialect) UpgradeHistoryTable(schema string, table string, version int) []string {
+	return nil
+}
+
+// Lock waits for the lock row, taking over a lock acquired more than staleLockAgeDefault ago
+func (d *cockroachDialect) Lock(ctx context.Context, conn *sql.Conn, schema string, table string) error {
+	return d.LockExpiring(ctx, conn, schema, table, staleLockAgeDefault)
+}
+
+// LockExpiring inserts the lock row, which a run that crashed leaves behind. The row is taken over once it is older
+// than staleAge, a running migration must not outlast it
+func (d *cockroachDialect) LockExpiring(ctx context.Context, conn *sql.Conn, schema string, table string, staleAge time.Duration) error {
+
+	lockTable := table + "_lock"
+
+	_, err := conn.ExecContext(ctx, fillTemplate(createLockTableCockroachDb, d, schema, lockTable))
+	if err != nil {
+		return err
+	}
+
+	owner, err := newLockOwner()
+	if err != nil {
+		return err
+	}
+
+	for {
+		acquired, err := d.acquireLock(ctx, conn, schema, lockTable, owner, staleAge)
+		if err != nil && !d.IsRetryable(err) {
+			return err
+		}
+
+		if acquired {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(cockroachLockPollInterval):
+		}
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.owners == nil {
+		d.owners = map[*sql.Conn]string{}
+	}
+	d.owners[conn] = owner
+
+	return nil
+}
+
+// acquireLock Insert the lock row for owner, or take it over when it is older than staleAge
+func (d *cockroachDialect) acquireLock(ctx context.Context, conn *sql.Conn, schema string, lockTable string, owner string, staleAge time.Duration) (bool, error) {
+
+	r, err := conn.ExecContext(ctx, fillTemplate(lockCockroachDb, d, schema, lockTable), owner)
+	if err != nil {
+		return false, err
+	}
+
+	if inserted, _ := r.RowsAffected(); inserted == 1 || staleAge <= 0 {
+		return inserted == 1, nil
+	}
+
+	r, err = conn.ExecContext(ctx, fillTemplate(takeOverLockCockroachDb, d, schema, lockTable), owner, staleAge.Milliseconds())
+	if err != nil {
+		return false, err
+	}
+
+	takenOver, _ := r.RowsAffected()
+	return takenOver == 1, nil
+}
+
+func (d *cockroachDialect) Unlock(ctx context.Context, conn *sql.Conn, schema string, table string) error {
+
+	d.mu.Lock()
+	owner, ok := d.owners[conn]
+	delete(d.owners, conn)
+	d.mu.Unlock()
+
+	if !ok {
+		return nil
+	}
+
+	_, err := conn.ExecContext(ctx, fillTemplate(unlockCockroachDb, d, schema, table+"_lock"), owner)
+	return err
+}
+
+func (d *cockroachDialect) Clean(ctx context.Context, conn *sql.Conn, schemas []string) error {
+
+	if len(schemas) <= 0 {
+		var current string
+		if err := conn.QueryRowContext(ctx, currentSchemaPostgres).Scan(&current); err != nil {
+			return err
+		}
+		schemas = []string{current}
+	}
+
+	for _, s := range schemas {
+		if err := execGeneratedStatements(ctx, conn, cleanCockroachDb, s); err != nil {
+			return fmt.Errorf("error cleaning schema %s: %w", s, err)
+		}
+	}
+
+	return nil
+}
+
+// TransactionalDDL is false, CockroachDB commits schema changes asynchronously after their transaction, a failed
+// script may leave them applied
+func (d *cockroachDialect) TransactionalDDL() bool {
+	return false
+}
+
+// LintRules leaves out LintNonConcurrentIndex, CockroachDB builds indexes online
+func (d *cockroachDialect) LintRules() []LintRule {
+	return lintRulesDefault
+}
+
+// IsRetryable reports serializable transaction conflicts (SQLSTATE 40001), which CockroachDB expects clients to retry,
+// besides the PostgreSQL lock errors
+func (d *cockroachDialect) IsRetryable(err error) bool {
+	return isSerializationConflict(err) || d.postgresDialect.IsRetryable(err)
+}
+
+// DefaultRetries retries serializable transaction conflicts even when ScriptRetries is not set, the lock errors are
+// retried only when it is
+func (d *cockroachDialect) DefaultRetries(err error) int {
+
+	if isSerializationConflict(err) {
+		return cockroachRetriesDefault
+	}
+
+	return 0
+}
+
+// isSerializationConflict Reports the SQLSTATE 40001 errors, also reported as "restart transaction" by CockroachDB
+func isSerializationConflict(err error) bool {
+
+	if sqlState(err) == "40001" {
+		return true
+	}
+
+	return err != nil && strings.Contains(err.Error(), "restart transaction")
+}
+
+// sqlState Returns the SQLSTATE code of a Postgres wire protocol error, empty when unknown.
+// Both lib/pq and pgx errors expose the code without goflyway importing them
+func sqlState(err error) string {
+
+	var withSQLState interface{ SQLState() string }
+	if errors.As(err, &withSQLState) {
+		return withSQLState.SQLState()
+	}
+
+	// lib/pq before v1.10
+	var withFields interface{ Get(k byte) string }
+	if errors.As(err, &withFields) {
+		return withFields.Get('C')
+	}
+
+	return ""
+}
+
+func newLockOwner() (string, error) {
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,167 @@
+package goflyway
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// mariadbDialect differs from MySQL on the history table layout, which avoids the implicit
+// "ON UPDATE CURRENT_TIMESTAMP" of TIMESTAMP columns, on installed_by, stored without the host
+// part of CURRENT_USER(), and it checks the history table existence instead of relying on the
+// warning raised by CREATE TABLE IF NOT EXISTS
+type mariadbDialect struct {
+	mysqlDialect
+}
+
+func (d *mariadbDialect) CreateHistoryTable(schema string, table string) string {
+	return fillHistoryTemplate(createTableMariaDb, d, schema, table)
+}
+
+func (d *mariadbDialect) InsertHistory(schema string, table string) string {
+	return fillTemplate(insertMariaDb, d, schema, table)
+}
+
+// CurrentUser strips the host part of CURRENT_USER()
+func (d *mariadbDialect) CurrentUser() string {
+	return currentUserMariaDb
+}
+
+func (d *mariadbDialect) HistoryTableExists(ctx context.Context, db Executor, schema string, table string) (bool, error) {
+
+	var total int
+	err := queryRow(ctx, db, historyTableExistsMariaDb, []interface{}{schema, table}, &total)
+	if err != nil {
+		return false, err
+	}
+
+	return total > 0, nil
+}
+
+// SessionTimeouts bounds statements with max_statement_time, in seconds, which also applies to DDL
+func (d *mariadbDialect) SessionTimeouts(lockTimeout time.Duration, statementTimeout time.Duration) ([]string, error) {
+
+	commands, err := d.mysqlDialect.SessionTimeouts(lockTimeout, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if statementTimeout > 0 {
+		commands = append(commands, fmt.Sprintf(statementTimeoutMariaDb, statementTimeout.Seconds()))
+	}
+
+	return commands, nil
+}
+
+// ExceededTimeout reports ER_STATEMENT_TIMEOUT (1969) besides the MySQL lock wait timeout
+func (d *mariadbDialect) ExceededTimeout(err error) (string, bool) {
+
+	if mysqlErrorNumber(err) == 1969 {
+		return "max_statement_time", false
+	}
+
+	return d.mysqlDialect.ExceededTimeout(err)
+}
+
+// Lock checks the server is MariaDB before taking the lock, so a MYSQL server configured as MARIADB is reported early
+func (d *mariadbDialect) Lock(ctx context.Context, conn *sql.Conn, schema string, table string) error {
+
+	if _, err := mariadbVersion(ctx, conn); err != nil {
+		return err
+	}
+
+	return d.mysqlDialect.Lock(ctx, conn, schema, table)
+}
+
+func (d *mariadbDialect) TransactionalDDL() bool {
+	return false
+}
+
+func (d *mariadbDialect) Clean(ctx context.Context, conn *sql.Conn, schemas []string) error {
+
+	version, err := mariadbVersion(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	if len(schemas) <= 0 {
+		var current sql.NullString
+		if err := conn.QueryRowContext(ctx, currentSchemaMysql).Scan(&current); err != nil {
+			return err
+		}
+		schemas = []string{current.String}
+	}
+
+	if err = d.mysqlDialect.Clean(ctx, conn, schemas); err != nil {
+		return err
+	}
+
+	// sequences exist since 10.3 and are not listed as tables
+	if version.atLeast(10, 3) {
+		for _, s := range schemas {
+			if err := execGeneratedStatements(ctx, conn, cleanSequencesMariaDb, s); err != nil {
+				return fmt.Errorf("error cleaning schema %s: %w", s, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+type serverVersion struct {
+	Major int
+	Minor int
+	Raw   string
+}
+
+func (v serverVersion) atLeast(major int, minor int) bool {
+	return v.Major > major || (v.Major == major && v.Minor >= minor)
+}
+
+type rowQuerier interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// mariadbVersion Detects the server version with SELECT VERSION(), failing when the server is not MariaDB
+func mariadbVersion(ctx context.Context, q rowQuerier) (serverVersion, error) {
+
+	var raw string
+	if err := q.QueryRowContext(ctx, versionMysql).Scan(&raw); err != nil {
+		return serverVersion{}, err
+	}
+
+	v, err := parseServerVersion(raw)
+	if err != nil {
+		return v, err
+	}
+
+	if !strings.Contains(strings.ToLower(raw), "mariadb") {
+		return v, fmt.Errorf("server version %s is not MariaDB, use driver %s", raw, MYSQL)
+	}
+
+	return v, nil
+}
+
+// parseServerVersion Parse the major and minor numbers of versions like "10.11.4-MariaDB-1:10.11.4+maria~ubu2204"
+func parseServerVersion(raw string) (serverVersion, error) {
+
+	v := serverVersion{Raw: raw}
+
+	numbers := strings.SplitN(strings.SplitN(raw, "-", 2)[0], ".", 3)
+	if len(numbers) < 2 {
+		return v, fmt.Errorf("unrecognized server version %s", raw)
+	}
+
+	var err error
+	if v.Major, err = strconv.Atoi(numbers[0]); err != nil {
+		return v, fmt.Errorf("unrecognized server version %s", raw)
+	}
+	if v.Minor, err = strconv.Atoi(numbers[1]); err != nil {
+		return v, fmt.Errorf("unrecognized server version %s", raw)
+	}
+
+	return v, nil
+}
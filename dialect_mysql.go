@@ -0,0 +1,252 @@
+package goflyway
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type mysqlDialect struct{}
+
+func (d *mysqlDialect) QuoteIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+func (d *mysqlDialect) CreateSchema(schema string) (string, error) {
+	return fillTemplate(createSchemaMysql, d, schema, ""), nil
+}
+
+// MaintenanceDSN connects to information_schema, dsn is a go-sql-driver/mysql connection string
+// [user[:password]@][net[(addr)]]/dbname[?params]
+func (d *mysqlDialect) MaintenanceDSN(dsn string) (string, string, error) {
+
+	slash := strings.LastIndex(dsn, "/")
+	if slash < 0 {
+		return "", "", fmt.Errorf("connection string has no database")
+	}
+
+	database, params, hasParams := strings.Cut(dsn[slash+1:], "?")
+	if len(database) <= 0 {
+		return "", "", fmt.Errorf("connection string has no database")
+	}
+
+	maintenance := dsn[:slash+1] + "information_schema"
+	if hasParams {
+		maintenance += "?" + params
+	}
+
+	return maintenance, database, nil
+}
+
+func (d *mysqlDialect) DatabaseExists() string {
+	return databaseExistsMysql
+}
+
+func (d *mysqlDialect) CreateDatabase(database string, encoding string, collation string) (string, error) {
+
+	command := fillDatabaseTemplate(createDatabaseMysql, d, database)
+
+	if len(encoding) > 0 {
+		command += " CHARACTER SET " + encoding
+	}
+
+	if len(collation) > 0 {
+		command += " COLLATE " + collation
+	}
+
+	return command, nil
+}
+
+// SessionTimeouts bounds metadata and row lock waits, in seconds. max_execution_time only bounds SELECT statements
+func (d *mysqlDialect) SessionTimeouts(lockTimeout time.Duration, statementTimeout time.Duration) ([]string, error) {
+
+	commands := []string{}
+
+	if lockTimeout > 0 {
+		seconds := int64(math.Ceil(lockTimeout.Seconds()))
+		if seconds < 1 {
+			seconds = 1
+		}
+		commands = append(commands, fmt.Sprintf(lockTimeoutMysql, seconds), fmt.Sprintf(innodbLockTimeoutMysql, seconds))
+	}
+
+	if statementTimeout > 0 {
+		commands = append(commands, fmt.Sprintf(statementTimeoutMysql, statementTimeout.Milliseconds()))
+	}
+
+	return commands, nil
+}
+
+// ExceededTimeout reports ER_LOCK_WAIT_TIMEOUT (1205) and ER_QUERY_TIMEOUT (3024)
+func (d *mysqlDialect) ExceededTimeout(err error) (string, bool) {
+
+	switch mysqlErrorNumber(err) {
+	case 1205:
+		return "lock_wait_timeout", true
+	case 3024:
+		return "max_execution_time", false
+	}
+
+	return "", false
+}
+
+// IsRetryable reports ER_LOCK_WAIT_TIMEOUT (1205) and ER_LOCK_DEADLOCK (1213)
+func (d *mysqlDialect) IsRetryable(err error) bool {
+
+	switch mysqlErrorNumber(err) {
+	case 1205, 1213:
+		return true
+	}
+
+	return false
+}
+
+func (d *mysqlDialect) CreateHistoryTable(schema string, table string) string {
+	return fillHistoryTemplate(createTableMysql, d, schema, table)
+}
+
+func (d *mysqlDialect) CreateFlywayHistoryTable(schema string, table string) string {
+	return fillHistoryTemplate(createTableFlywayMysql, d, schema, table)
+}
+
+func (d *mysqlDialect) SelectHistory(schema string, table string) string {
+	return fillTemplate(selectTableMysql, d, schema, table)
+}
+
+func (d *mysqlDialect) InsertHistory(schema string, table string) string {
+	return fillTemplate(insertMysql, d, schema, table)
+}
+
+func (d *mysqlDialect) ParamStyle() ParamStyle {
+	return ParamPositional
+}
+
+func (d *mysqlDialect) CreateLayoutTable(schema string, layoutTable string) string {
+	return fillTemplate(createLayoutTableMysql, d, schema, layoutTable)
+}
+
+// UpgradeHistoryTable has nothing to upgrade to layout 2, primary keys are always named PRIMARY
+func (d *mysqlDialect) UpgradeHistoryTable(schema string, table string, version int) []string {
+	return nil
+}
+
+func (d *mysqlDialect) UpdateHistory(schema string, table string) string {
+	return fillTemplate(updateMysql, d, schema, table)
+}
+
+func (d *mysqlDialect) DeleteHistory(schema string, table string) string {
+	return fillTemplate(deleteMysql, d, schema, table)
+}
+
+func (d *mysqlDialect) SearchPath(schemas []string) string {
+	return ""
+}
+
+func (d *mysqlDialect) CurrentUser() string {
+	return currentUserMysql
+}
+
+// TimestampLayouts parses DATETIME text returned when the connection does not set parseTime,
+// the history select returns seconds since epoch
+func (d *mysqlDialect) TimestampLayouts() []string {
+	return []string{
+		"2006-01-02 15:04:05.999999",
+		time.RFC3339Nano,
+	}
+}
+
+func (d *mysqlDialect) Lock(ctx context.Context, conn *sql.Conn, schema string, table string) error {
+
+	var acquired sql.NullInt64
+	err := conn.QueryRowContext(ctx, lockMysql, mysqlLockName(schema, table)).Scan(&acquired)
+	if err != nil {
+		return err
+	}
+
+	if !acquired.Valid || acquired.Int64 != 1 {
+		return fmt.Errorf("could not acquire lock %s", mysqlLockName(schema, table))
+	}
+
+	return nil
+}
+
+func (d *mysqlDialect) Unlock(ctx context.Context, conn *sql.Conn, schema string, table string) error {
+	_, err := conn.ExecContext(ctx, unlockMysql, mysqlLockName(schema, table))
+	return err
+}
+
+// LintStatements splits the script as it is executed
+func (d *mysqlDialect) LintStatements(script string) []string {
+	return d.SplitStatements(script)
+}
+
+// LintRules leaves out LintNonConcurrentIndex, InnoDB builds indexes without blocking writes
+func (d *mysqlDialect) LintRules() []LintRule {
+	return lintRulesDefault
+}
+
+// SplitStatements splits the script on ';' or on the delimiter set by the DELIMITER command,
+// so connections do not need multiStatements enabled
+func (d *mysqlDialect) SplitStatements(script string) []string {
+	return splitStatements(script, splitOptions{
+		hashComments:     true,
+		backticks:        true,
+		delimiterCommand: true,
+		backslashEscapes: true,
+	})
+}
+
+func (d *mysqlDialect) Clean(ctx context.Context, conn *sql.Conn, schemas []string) error {
+
+	if len(schemas) <= 0 {
+		var current sql.NullString
+		if err := conn.QueryRowContext(ctx, currentSchemaMysql).Scan(&current); err != nil {
+			return err
+		}
+		schemas = []string{current.String}
+	}
+
+	if _, err := conn.ExecContext(ctx, "SET FOREIGN_KEY_CHECKS = 0"); err != nil {
+		return err
+	}
+	defer conn.ExecContext(ctx, "SET FOREIGN_KEY_CHECKS = 1")
+
+	for _, s := range schemas {
+		if err := execGeneratedStatements(ctx, conn, cleanMysql, s, s); err != nil {
+			return fmt.Errorf("error cleaning schema %s: %w", s, err)
+		}
+	}
+
+	return nil
+}
+
+// TransactionalDDL is false, DDL statements commit the script transaction implicitly
+func (d *mysqlDialect) TransactionalDDL() bool {
+	return false
+}
+
+func mysqlLockName(schema string, table string) string {
+	return fmt.Sprintf("goflyway_%d", lockKey(schema, table))
+}
+
+// mysqlErrorNumber Returns the error number of a go-sql-driver/mysql error, read from its message so goflyway does not
+// import the driver. Zero when unknown
+func mysqlErrorNumber(err error) int {
+
+	if err == nil {
+		return 0
+	}
+
+	m := regexMysqlErrorNumber.FindStringSubmatch(err.Error())
+	if m == nil {
+		return 0
+	}
+
+	n, _ := strconv.Atoi(m[1])
+
+	return n
+}
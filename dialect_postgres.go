@@ -0,0 +1,261 @@
+package goflyway
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+type postgresDialect struct{}
+
+func (d *postgresDialect) QuoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func (d *postgresDialect) CreateSchema(schema string) (string, error) {
+	return fillTemplate(createSchemaPostgres, d, schema, ""), nil
+}
+
+func (d *postgresDialect) CreateHistoryTable(schema string, table string) string {
+	return fillHistoryTemplate(createTablePostgres, d, schema, table)
+}
+
+func (d *postgresDialect) CreateFlywayHistoryTable(schema string, table string) string {
+	return fillHistoryTemplate(createTableFlywayPostgres, d, schema, table)
+}
+
+func (d *postgresDialect) SelectHistory(schema string, table string) string {
+	return fillTemplate(selectTablePostgres, d, schema, table)
+}
+
+func (d *postgresDialect) InsertHistory(schema string, table string) string {
+	return fillTemplate(insertPostgres, d, schema, table)
+}
+
+func (d *postgresDialect) ParamStyle() ParamStyle {
+	return ParamPositional
+}
+
+func (d *postgresDialect) CreateLayoutTable(schema string, layoutTable string) string {
+	return fillTemplate(createLayoutTablePostgres, d, schema, layoutTable)
+}
+
+func (d *postgresDialect) UpgradeHistoryTable(schema string, table string, version int) []string {
+
+	if version == 2 {
+		return []string{fillHistoryTemplate(renamePrimaryKeyPostgres, d, schema, table)}
+	}
+
+	return nil
+}
+
+func (d *postgresDialect) UpdateHistory(schema string, table string) string {
+	return fillTemplate(updatePostgres, d, schema, table)
+}
+
+func (d *postgresDialect) DeleteHistory(schema string, table string) string {
+	return fillTemplate(deletePostgres, d, schema, table)
+}
+
+func (d *postgresDialect) SearchPath(schemas []string) string {
+
+	if len(schemas) <= 0 {
+		return ""
+	}
+
+	quoted := []string{}
+	for _, s := range schemas {
+		quoted = append(quoted, d.QuoteIdentifier(s))
+	}
+
+	return regexSchemaNames.ReplaceAllLiteralString(searchPathPostgres, strings.Join(quoted, ", "))
+}
+
+// CurrentSearchPath selects the search path of the transaction, set by the caller of MigrateTx or the session
+func (d *postgresDialect) CurrentSearchPath() string {
+	return currentSearchPathPostgres
+}
+
+// RestoreSearchPath uses set_config with is_local, which lasts until the end of the transaction like SET LOCAL
+func (d *postgresDialect) RestoreSearchPath() string {
+	return restoreSearchPathPostgres
+}
+
+// NonTransactional reports the concurrent index builds, VACUUM, database and tablespace changes and ALTER SYSTEM,
+// which PostgreSQL refuses in a transaction block
+func (d *postgresDialect) NonTransactional(statement string) bool {
+	return regexNonTransactionalPostgres.MatchString(statement)
+}
+
+func (d *postgresDialect) CurrentUser() string {
+	return currentUserPostgres
+}
+
+// MaintenanceDSN connects to the postgres database, dsn is a URL or keyword/value connection string
+func (d *postgresDialect) MaintenanceDSN(dsn string) (string, string, error) {
+	return replaceDSNDatabasePostgres(dsn, "postgres")
+}
+
+func (d *postgresDialect) DatabaseExists() string {
+	return databaseExistsPostgres
+}
+
+// CreateDatabase copies template0 when encoding or collation are set, template1 may have other ones
+func (d *postgresDialect) CreateDatabase(database string, encoding string, collation string) (string, error) {
+
+	command := fillDatabaseTemplate(createDatabasePostgres, d, database)
+
+	if len(encoding) > 0 || len(collation) > 0 {
+		command += " TEMPLATE template0"
+	}
+
+	if len(encoding) > 0 {
+		command += fmt.Sprintf(" ENCODING '%s'", quoteLiteral(encoding))
+	}
+
+	if len(collation) > 0 {
+		command += fmt.Sprintf(" LC_COLLATE '%s' LC_CTYPE '%s'", quoteLiteral(collation), quoteLiteral(collation))
+	}
+
+	return command, nil
+}
+
+// SessionTimeouts sets lock_timeout and statement_timeout in milliseconds
+func (d *postgresDialect) SessionTimeouts(lockTimeout time.Duration, statementTimeout time.Duration) ([]string, error) {
+
+	commands := []string{}
+
+	if lockTimeout > 0 {
+		commands = append(commands, fmt.Sprintf(lockTimeoutPostgres, lockTimeout.Milliseconds()))
+	}
+
+	if statementTimeout > 0 {
+		commands = append(commands, fmt.Sprintf(statementTimeoutPostgres, statementTimeout.Milliseconds()))
+	}
+
+	return commands, nil
+}
+
+// ExceededTimeout reports lock_not_available (SQLSTATE 55P03) and query_canceled (57014) by statement timeout
+func (d *postgresDialect) ExceededTimeout(err error) (string, bool) {
+
+	switch code := sqlState(err); {
+	case code == "55P03":
+		return "lock_timeout", true
+	case code == "57014" && strings.Contains(err.Error(), "statement timeout"):
+		return "statement_timeout", false
+	}
+
+	return "", false
+}
+
+// IsRetryable reports deadlock_detected (SQLSTATE 40P01) and lock_not_available (55P03), raised by lock_timeout
+func (d *postgresDialect) IsRetryable(err error) bool {
+
+	switch sqlState(err) {
+	case "40P01", "55P03":
+		return true
+	}
+
+	return false
+}
+
+// HistoryTableExists reads information_schema, a failed probe of the table would abort the transaction of MigrateTx
+func (d *postgresDialect) HistoryTableExists(ctx context.Context, db Executor, schema string, table string) (bool, error) {
+
+	var total int
+	err := queryRow(ctx, db, historyTableExistsPostgres, []interface{}{schema, table}, &total)
+	if err != nil {
+		return false, err
+	}
+
+	return total > 0, nil
+}
+
+func (d *postgresDialect) TimestampLayouts() []string {
+	return []string{
+		time.RFC3339Nano,
+		"2006-01-02 15:04:05.999999999-07",
+		"2006-01-02 15:04:05.999999999-07:00",
+		"2006-01-02 15:04:05.999999999",
+	}
+}
+
+func (d *postgresDialect) Lock(ctx context.Context, conn *sql.Conn, schema string, table string) error {
+	_, err := conn.ExecContext(ctx, lockPostgres, lockKey(schema, table))
+	return err
+}
+
+func (d *postgresDialect) Unlock(ctx context.Context, conn *sql.Conn, schema string, table string) error {
+	_, err := conn.ExecContext(ctx, unlockPostgres, lockKey(schema, table))
+	return err
+}
+
+// SplitStatements keeps the script as a single statement, the simple query protocol runs multiple statements at once
+func (d *postgresDialect) SplitStatements(script string) []string {
+	return []string{script}
+}
+
+// LintStatements splits the script on semicolons outside dollar quoted function bodies
+func (d *postgresDialect) LintStatements(script string) []string {
+	return splitStatements(script, splitOptions{dollarQuotes: true})
+}
+
+// LintRules checks every rule, CREATE INDEX takes a SHARE lock that blocks writes until the index is built
+func (d *postgresDialect) LintRules() []LintRule {
+	return append(append([]LintRule{}, lintRulesDefault...), LintNonConcurrentIndex)
+}
+
+func (d *postgresDialect) Clean(ctx context.Context, conn *sql.Conn, schemas []string) error {
+
+	if len(schemas) <= 0 {
+		var current string
+		if err := conn.QueryRowContext(ctx, currentSchemaPostgres).Scan(&current); err != nil {
+			return err
+		}
+		schemas = []string{current}
+	}
+
+	for _, s := range schemas {
+		if err := execGeneratedStatements(ctx, conn, cleanPostgres, s); err != nil {
+			return fmt.Errorf("error cleaning schema %s: %w", s, err)
+		}
+	}
+
+	return nil
+}
+
+// replaceDSNDatabasePostgres Returns dsn connected to database instead, and the database it was connected to
+func replaceDSNDatabasePostgres(dsn string, database string) (string, string, error) {
+
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+
+		u, err := url.Parse(dsn)
+		if err != nil {
+			return "", "", err
+		}
+
+		current := strings.TrimPrefix(u.Path, "/")
+		if len(current) <= 0 {
+			return "", "", fmt.Errorf("connection string has no database")
+		}
+		u.Path = "/" + database
+
+		return u.String(), current, nil
+	}
+
+	m := regexDbNamePostgres.FindStringSubmatch(dsn)
+	if m == nil || len(m[2]) <= 0 {
+		return "", "", fmt.Errorf("connection string has no dbname")
+	}
+
+	current := m[2]
+	if strings.HasPrefix(current, "'") {
+		current = strings.NewReplacer(`\'`, "'", `\\`, `\`).Replace(current[1 : len(current)-1])
+	}
+
+	return regexDbNamePostgres.ReplaceAllLiteralString(dsn, m[1]+"dbname="+database), current, nil
+}
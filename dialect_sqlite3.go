@@ -0,0 +1,176 @@
+package goflyway
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type sqlite3Dialect struct{}
+
+func (d *sqlite3Dialect) QuoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// CreateSchema is not supported, sqlite schemas are attached databases
+func (d *sqlite3Dialect) CreateSchema(schema string) (string, error) {
+	return "", fmt.Errorf("%w: %s", ErrCreateSchemasNotSupported, SQLITE3)
+}
+
+// SessionTimeouts bounds the wait for the database lock with busy_timeout, statements are not bounded
+func (d *sqlite3Dialect) SessionTimeouts(lockTimeout time.Duration, statementTimeout time.Duration) ([]string, error) {
+
+	if statementTimeout > 0 {
+		return nil, fmt.Errorf("%w: statement timeout on %s, use ScriptTimeout", ErrSessionTimeoutNotSupported, SQLITE3)
+	}
+
+	if lockTimeout > 0 {
+		return []string{fmt.Sprintf(busyTimeoutSqlite3, lockTimeout.Milliseconds())}, nil
+	}
+
+	return nil, nil
+}
+
+// ExceededTimeout reports SQLITE_BUSY, returned when busy_timeout expires
+func (d *sqlite3Dialect) ExceededTimeout(err error) (string, bool) {
+
+	if d.IsRetryable(err) {
+		return "busy_timeout", true
+	}
+
+	return "", false
+}
+
+// IsRetryable reports SQLITE_BUSY, the database is locked by another connection
+func (d *sqlite3Dialect) IsRetryable(err error) bool {
+	return err != nil && (strings.Contains(err.Error(), "SQLITE_BUSY") || strings.Contains(err.Error(), "database is locked"))
+}
+
+func (d *sqlite3Dialect) CreateHistoryTable(schema string, table string) string {
+	return fillHistoryTemplate(createTableSqlite3, d, schema, table)
+}
+
+func (d *sqlite3Dialect) CreateFlywayHistoryTable(schema string, table string) string {
+	return fillHistoryTemplate(createTableFlywaySqlite3, d, schema, table)
+}
+
+func (d *sqlite3Dialect) SelectHistory(schema string, table string) string {
+	return fillTemplate(selectTableSqlite3, d, schema, table)
+}
+
+func (d *sqlite3Dialect) InsertHistory(schema string, table string) string {
+	return fillTemplate(insertSqlite3, d, schema, table)
+}
+
+func (d *sqlite3Dialect) ParamStyle() ParamStyle {
+	return ParamPositional
+}
+
+func (d *sqlite3Dialect) CreateLayoutTable(schema string, layoutTable string) string {
+	return fillTemplate(createLayoutTableSqlite3, d, schema, layoutTable)
+}
+
+// UpgradeHistoryTable has nothing to upgrade to layout 2, constraint names are local to their table
+func (d *sqlite3Dialect) UpgradeHistoryTable(schema string, table string, version int) []string {
+	return nil
+}
+
+func (d *sqlite3Dialect) UpdateHistory(schema string, table string) string {
+	return fillTemplate(updateSqlite3, d, schema, table)
+}
+
+func (d *sqlite3Dialect) DeleteHistory(schema string, table string) string {
+	return fillTemplate(deleteSqlite3, d, schema, table)
+}
+
+func (d *sqlite3Dialect) SearchPath(schemas []string) string {
+	return ""
+}
+
+// NonTransactional reports VACUUM, which SQLite refuses in a transaction
+func (d *sqlite3Dialect) NonTransactional(statement string) bool {
+	return regexNonTransactionalSqlite.MatchString(statement)
+}
+
+// TimestampLayouts parses CURRENT_TIMESTAMP text, stored in UTC, and the formats written by
+// github.com/mattn/go-sqlite3 and modernc.org/sqlite
+func (d *sqlite3Dialect) TimestampLayouts() []string {
+	return []string{
+		"2006-01-02 15:04:05",
+		time.RFC3339Nano,
+		"2006-01-02 15:04:05.999999999-07:00",
+		"2006-01-02 15:04:05.999999999",
+	}
+}
+
+// Lock is a no-op, sqlite serializes writers with its database file lock
+func (d *sqlite3Dialect) Lock(ctx context.Context, conn *sql.Conn, schema string, table string) error {
+	return nil
+}
+
+func (d *sqlite3Dialect) Unlock(ctx context.Context, conn *sql.Conn, schema string, table string) error {
+	return nil
+}
+
+// SplitStatements keeps the script as a single statement, sqlite drivers run multiple statements at once
+func (d *sqlite3Dialect) SplitStatements(script string) []string {
+	return []string{script}
+}
+
+func (d *sqlite3Dialect) Clean(ctx context.Context, conn *sql.Conn, schemas []string) error {
+
+	if len(schemas) <= 0 {
+		schemas = []string{"main"}
+	}
+
+	var foreignKeys int
+	if err := conn.QueryRowContext(ctx, "PRAGMA foreign_keys").Scan(&foreignKeys); err != nil {
+		return err
+	}
+
+	if _, err := conn.ExecContext(ctx, "PRAGMA foreign_keys = OFF"); err != nil {
+		return err
+	}
+	defer conn.ExecContext(ctx, fmt.Sprintf("PRAGMA foreign_keys = %d", foreignKeys))
+
+	for _, s := range schemas {
+		if err := d.cleanSchema(ctx, conn, s); err != nil {
+			return fmt.Errorf("error cleaning schema %s: %w", s, err)
+		}
+	}
+
+	return nil
+}
+
+func (d *sqlite3Dialect) cleanSchema(ctx context.Context, conn *sql.Conn, schema string) error {
+
+	rows, err := conn.QueryContext(ctx, fillTemplate(cleanSqlite3, d, schema, ""))
+	if err != nil {
+		return err
+	}
+
+	statements := []string{}
+	for rows.Next() {
+		var objectType, name string
+		if err = rows.Scan(&objectType, &name); err != nil {
+			rows.Close()
+			return err
+		}
+		statements = append(statements, fmt.Sprintf("DROP %s IF EXISTS %s", strings.ToUpper(objectType), qualifiedTableName(d, schema, name)))
+	}
+	rows.Close()
+
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	for _, s := range statements {
+		if _, err = conn.ExecContext(ctx, s); err != nil {
+			return fmt.Errorf("%s: %w", s, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,265 @@
+package goflyway
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var regexBatchSeparator = regexp.MustCompile(`(?im)^[ \t]*GO[ \t]*;?[ \t]*$`)
+
+type sqlServerDialect struct{}
+
+func (d *sqlServerDialect) QuoteIdentifier(name string) string {
+	return "[" + strings.ReplaceAll(name, "]", "]]") + "]"
+}
+
+func (d *sqlServerDialect) CreateSchema(schema string) (string, error) {
+	return fillTemplate(createSchemaMsSqlServer, d, schema, ""), nil
+}
+
+// MaintenanceDSN connects to master, dsn is a sqlserver:// URL or an ADO connection string
+func (d *sqlServerDialect) MaintenanceDSN(dsn string) (string, string, error) {
+
+	if strings.HasPrefix(dsn, "sqlserver://") {
+
+		u, err := url.Parse(dsn)
+		if err != nil {
+			return "", "", err
+		}
+
+		q := u.Query()
+		database := q.Get("database")
+		if len(database) <= 0 {
+			return "", "", fmt.Errorf("connection string has no database")
+		}
+		q.Set("database", "master")
+		u.RawQuery = q.Encode()
+
+		return u.String(), database, nil
+	}
+
+	database := ""
+	params := strings.Split(dsn, ";")
+	for i, p := range params {
+
+		key, value, ok := strings.Cut(p, "=")
+		if k := strings.ToLower(strings.TrimSpace(key)); ok && (k == "database" || k == "initial catalog") {
+			database = strings.TrimSpace(value)
+			params[i] = key + "=master"
+		}
+	}
+
+	if len(database) <= 0 {
+		return "", "", fmt.Errorf("connection string has no database")
+	}
+
+	return strings.Join(params, ";"), database, nil
+}
+
+func (d *sqlServerDialect) DatabaseExists() string {
+	return databaseExistsMsSqlServer
+}
+
+// CreateDatabase sets the encoding through the collation, such as Latin1_General_100_CI_AS_SC_UTF8
+func (d *sqlServerDialect) CreateDatabase(database string, encoding string, collation string) (string, error) {
+
+	if len(encoding) > 0 {
+		return "", fmt.Errorf("database encoding is not supported by %s, use a collation such as Latin1_General_100_CI_AS_SC_UTF8", MSSQLSERVER)
+	}
+
+	command := fillDatabaseTemplate(createDatabaseMsSqlServer, d, database)
+
+	if len(collation) > 0 {
+		command += " COLLATE " + collation
+	}
+
+	return command, nil
+}
+
+// SessionTimeouts bounds lock waits only, the server has no statement timeout, see GoFlywayConfig.ScriptTimeout
+func (d *sqlServerDialect) SessionTimeouts(lockTimeout time.Duration, statementTimeout time.Duration) ([]string, error) {
+
+	if statementTimeout > 0 {
+		return nil, fmt.Errorf("%w: statement timeout on %s, use ScriptTimeout", ErrSessionTimeoutNotSupported, MSSQLSERVER)
+	}
+
+	if lockTimeout > 0 {
+		return []string{fmt.Sprintf(lockTimeoutMsSqlServer, lockTimeout.Milliseconds())}, nil
+	}
+
+	return nil, nil
+}
+
+// ExceededTimeout reports error 1222, lock request time out period exceeded
+func (d *sqlServerDialect) ExceededTimeout(err error) (string, bool) {
+
+	if sqlServerErrorNumber(err) == 1222 {
+		return "LOCK_TIMEOUT", true
+	}
+
+	return "", false
+}
+
+// IsRetryable reports error 1205, chosen as deadlock victim, and 1222, lock request time out period exceeded
+func (d *sqlServerDialect) IsRetryable(err error) bool {
+
+	switch sqlServerErrorNumber(err) {
+	case 1205, 1222:
+		return true
+	}
+
+	return false
+}
+
+func (d *sqlServerDialect) CreateHistoryTable(schema string, table string) string {
+	return fillHistoryTemplate(createTableMsSqlServer, d, schema, table)
+}
+
+func (d *sqlServerDialect) CreateFlywayHistoryTable(schema string, table string) string {
+	return fillHistoryTemplate(createTableFlywayMsSqlServer, d, schema, table)
+}
+
+func (d *sqlServerDialect) SelectHistory(schema string, table string) string {
+	return fillTemplate(selectTableMsSqlServer, d, schema, table)
+}
+
+func (d *sqlServerDialect) InsertHistory(schema string, table string) string {
+	return fillTemplate(insertMsSqlServer, d, schema, table)
+}
+
+func (d *sqlServerDialect) ParamStyle() ParamStyle {
+	return ParamNamed
+}
+
+func (d *sqlServerDialect) CreateLayoutTable(schema string, layoutTable string) string {
+	return fillTemplate(createLayoutTableMsSqlServer, d, schema, layoutTable)
+}
+
+func (d *sqlServerDialect) UpgradeHistoryTable(schema string, table string, version int) []string {
+
+	if version == 2 {
+		rename := fillTemplate(renamePrimaryKeyMsSqlServer, d, schema, legacyPrimaryKeyName)
+		return []string{regexPrimaryKeyName.ReplaceAllLiteralString(rename, quoteLiteral(table+"_pk"))}
+	}
+
+	return nil
+}
+
+func (d *sqlServerDialect) UpdateHistory(schema string, table string) string {
+	return fillTemplate(updateMsSqlServer, d, schema, table)
+}
+
+func (d *sqlServerDialect) DeleteHistory(schema string, table string) string {
+	return fillTemplate(deleteMsSqlServer, d, schema, table)
+}
+
+func (d *sqlServerDialect) SearchPath(schemas []string) string {
+	return ""
+}
+
+// NonTransactional reports the database and full-text catalog changes, backups, restores and RECONFIGURE, which SQL
+// Server refuses in a user transaction
+func (d *sqlServerDialect) NonTransactional(statement string) bool {
+	return regexNonTransactionalSqlServer.MatchString(statement)
+}
+
+func (d *sqlServerDialect) CurrentUser() string {
+	return currentUserMsSqlServer
+}
+
+func (d *sqlServerDialect) TimestampLayouts() []string {
+	return []string{
+		time.RFC3339Nano,
+		"2006-01-02 15:04:05.9999999 -07:00",
+		"2006-01-02 15:04:05.999",
+	}
+}
+
+func (d *sqlServerDialect) Lock(ctx context.Context, conn *sql.Conn, schema string, table string) error {
+
+	var result int
+	err := conn.QueryRowContext(ctx, lockMsSqlServer, sql.Named("resource", sqlServerLockName(schema, table))).Scan(&result)
+	if err != nil {
+		return err
+	}
+
+	if result < 0 {
+		return fmt.Errorf("could not acquire lock %s: sp_getapplock returned %d", sqlServerLockName(schema, table), result)
+	}
+
+	return nil
+}
+
+func (d *sqlServerDialect) Unlock(ctx context.Context, conn *sql.Conn, schema string, table string) error {
+	_, err := conn.ExecContext(ctx, unlockMsSqlServer, sql.Named("resource", sqlServerLockName(schema, table)))
+	return err
+}
+
+// LintStatements splits the GO batches of the script on semicolons
+func (d *sqlServerDialect) LintStatements(script string) []string {
+
+	statements := []string{}
+	for _, batch := range d.SplitStatements(script) {
+		statements = append(statements, splitStatements(batch, splitOptions{})...)
+	}
+
+	return statements
+}
+
+// LintRules leaves out LintNonConcurrentIndex, SQL Server has no CONCURRENTLY option
+func (d *sqlServerDialect) LintRules() []LintRule {
+	return lintRulesDefault
+}
+
+// SplitStatements splits the script into the batches delimited by GO lines
+func (d *sqlServerDialect) SplitStatements(script string) []string {
+
+	batches := []string{}
+	for _, b := range regexBatchSeparator.Split(script, -1) {
+		if len(strings.TrimSpace(b)) > 0 {
+			batches = append(batches, b)
+		}
+	}
+
+	return batches
+}
+
+func (d *sqlServerDialect) Clean(ctx context.Context, conn *sql.Conn, schemas []string) error {
+
+	if len(schemas) <= 0 {
+		var current string
+		if err := conn.QueryRowContext(ctx, currentSchemaMsSqlServer).Scan(&current); err != nil {
+			return err
+		}
+		schemas = []string{current}
+	}
+
+	for _, s := range schemas {
+		if err := execGeneratedStatements(ctx, conn, cleanMsSqlServer, sql.Named("schema", s)); err != nil {
+			return fmt.Errorf("error cleaning schema %s: %w", s, err)
+		}
+	}
+
+	return nil
+}
+
+func sqlServerLockName(schema string, table string) string {
+	return fmt.Sprintf("goflyway_%d", lockKey(schema, table))
+}
+
+// sqlServerErrorNumber Returns the error number of a go-mssqldb error, zero when unknown
+func sqlServerErrorNumber(err error) int32 {
+
+	var withNumber interface{ SQLErrorNumber() int32 }
+	if errors.As(err, &withNumber) {
+		return withNumber.SQLErrorNumber()
+	}
+
+	return 0
+}
@@ -0,0 +1,687 @@
+package goflyway
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestQualifiedTableName(t *testing.T) {
+
+	type QualifiedExpected struct {
+		Driver   Driver
+		Schema   string
+		Table    string
+		Expected string
+	}
+
+	names := []QualifiedExpected{
+		{Driver: POSTGRES, Table: "goflyway_schema_history", Expected: `"goflyway_schema_history"`},
+		{Driver: POSTGRES, Schema: "myschema", Table: "goflyway_schema_history", Expected: `"myschema"."goflyway_schema_history"`},
+		{Driver: MYSQL, Schema: "myschema", Table: "history", Expected: "`myschema`.`history`"},
+		{Driver: MSSQLSERVER, Schema: "dbo", Table: "history", Expected: "[dbo].[history]"},
+		{Driver: SQLITE3, Schema: "main", Table: `odd"name`, Expected: `"main"."odd""name"`},
+	}
+
+	for _, n := range names {
+		d, err := getDialect(n.Driver)
+		if err != nil {
+			t.Fatalf("expected nil but got error %v", err)
+		}
+
+		res := qualifiedTableName(d, n.Schema, n.Table)
+		if res != n.Expected {
+			t.Errorf("expected qualified name %s but got %s for driver %s", n.Expected, res, n.Driver)
+		}
+	}
+}
+
+func TestCreateHistoryTable_UsingSchema(t *testing.T) {
+
+	query := (&postgresDialect{}).CreateHistoryTable("myschema", "goflyway_schema_history")
+	if !strings.Contains(query, `CREATE TABLE IF NOT EXISTS "myschema"."goflyway_schema_history"`) {
+		t.Errorf("expected schema-qualified table in command but got %s", query)
+	}
+
+	query = (&sqlServerDialect{}).CreateHistoryTable("it's", "history")
+	if !strings.Contains(query, "OBJECT_ID('[it''s].[history]', 'U')") {
+		t.Errorf("expected escaped table literal in command but got %s", query)
+	}
+}
+
+func TestSearchPath(t *testing.T) {
+
+	query := (&postgresDialect{}).SearchPath([]string{"app", "public"})
+	if query != `SET LOCAL search_path TO "app", "public"` {
+		t.Errorf("unexpected search path command %s", query)
+	}
+
+	if query := (&mysqlDialect{}).SearchPath([]string{"app"}); len(query) > 0 {
+		t.Errorf("expected empty search path command for %s but got %s", MYSQL, query)
+	}
+}
+
+func TestCreateSchema_UnsupportedDriver(t *testing.T) {
+
+	_, err := (&sqlite3Dialect{}).CreateSchema("app")
+	if !errors.Is(err, ErrCreateSchemasNotSupported) {
+		t.Errorf("expected error %v but got %v", ErrCreateSchemasNotSupported, err)
+	}
+}
+
+func TestSplitStatements_Mysql(t *testing.T) {
+
+	script := "CREATE TABLE a (id INT, name VARCHAR(10) DEFAULT 'x;y'); -- comment; here\n" +
+		"# another; comment\n" +
+		"INSERT INTO `semi;colon` VALUES (1, 'it''s; fine');\n" +
+		"DELIMITER $$\n" +
+		"CREATE TRIGGER t BEFORE INSERT ON a FOR EACH ROW BEGIN SET NEW.name = 'z'; END$$\n" +
+		"DELIMITER ;\n" +
+		"/* block; comment */ DROP TABLE b;"
+
+	expected := []string{
+		"CREATE TABLE a (id INT, name VARCHAR(10) DEFAULT 'x;y')",
+		"-- comment; here\n# another; comment\nINSERT INTO `semi;colon` VALUES (1, 'it''s; fine')",
+		"CREATE TRIGGER t BEFORE INSERT ON a FOR EACH ROW BEGIN SET NEW.name = 'z'; END",
+		"/* block; comment */ DROP TABLE b",
+	}
+
+	res := (&mysqlDialect{}).SplitStatements(script)
+	if !reflect.DeepEqual(res, expected) {
+		t.Errorf("expected statements %q but got %q", expected, res)
+	}
+}
+
+func TestSplitStatements_BackslashEscapes(t *testing.T) {
+
+	mysql := (&mysqlDialect{}).SplitStatements(`INSERT INTO a VALUES ('it\'s; fine'); SELECT 2`)
+	if expected := []string{`INSERT INTO a VALUES ('it\'s; fine')`, "SELECT 2"}; !reflect.DeepEqual(mysql, expected) {
+		t.Errorf("expected %q but got %q", expected, mysql)
+	}
+
+	// a backslash is an ordinary character in standard SQL strings
+	postgres := (&postgresDialect{}).LintStatements(`INSERT INTO a VALUES ('C:\'); SELECT 2`)
+	if expected := []string{`INSERT INTO a VALUES ('C:\')`, "SELECT 2"}; !reflect.DeepEqual(postgres, expected) {
+		t.Errorf("expected %q but got %q", expected, postgres)
+	}
+}
+
+func TestSplitStatements_SqlServer(t *testing.T) {
+
+	script := "CREATE TABLE a (id INT);\nGO\nCREATE VIEW v AS SELECT id FROM a;\n  go  \n"
+
+	res := (&sqlServerDialect{}).SplitStatements(script)
+	if len(res) != 2 {
+		t.Fatalf("expected 2 batches but got %d: %q", len(res), res)
+	}
+
+	if strings.TrimSpace(res[1]) != "CREATE VIEW v AS SELECT id FROM a;" {
+		t.Errorf("unexpected second batch %q", res[1])
+	}
+}
+
+func TestLintStatements_Postgres(t *testing.T) {
+
+	script := "CREATE FUNCTION f() RETURNS int AS $body$ SELECT 1; $body$ LANGUAGE sql;\n" +
+		"DO $$ BEGIN PERFORM f(); END $$;\nSELECT $1"
+
+	expected := []string{
+		"CREATE FUNCTION f() RETURNS int AS $body$ SELECT 1; $body$ LANGUAGE sql",
+		"DO $$ BEGIN PERFORM f(); END $$",
+		"SELECT $1",
+	}
+
+	if res := (&postgresDialect{}).LintStatements(script); !reflect.DeepEqual(res, expected) {
+		t.Errorf("expected %q but got %q", expected, res)
+	}
+}
+
+type customDialect struct {
+	sqlite3Dialect
+}
+
+func TestRegisterDialect(t *testing.T) {
+
+	var custom Driver = "custom"
+
+	if _, err := getDialect(custom); !errors.Is(err, ErrUnsupportedDatabaseDriver) {
+		t.Fatalf("expected error %v but got %v", ErrUnsupportedDatabaseDriver, err)
+	}
+
+	RegisterDialect(custom, &customDialect{})
+
+	g, err := newGoFlywayRunner(GoFlywayConfig{
+		Driver:   custom,
+		Location: getWorkPath() + "/utils/test/db/custom-migration",
+	})
+
+	if err != nil {
+		t.Fatalf("errors happened when initialize goflywayrunner: %v", err)
+	}
+
+	if _, ok := g.dialect.(*customDialect); !ok {
+		t.Errorf("expected dialect of type %v but got %v", reflect.TypeOf(&customDialect{}), reflect.TypeOf(g.dialect))
+	}
+}
+
+func TestClean_Disabled(t *testing.T) {
+
+	err := Clean(GoFlywayConfig{
+		Driver:   POSTGRES,
+		Location: getWorkPath() + "/utils/test/db/custom-migration",
+	})
+
+	if !errors.Is(err, ErrCleanDisabled) {
+		t.Errorf("expected error %v but got %v", ErrCleanDisabled, err)
+	}
+}
+
+type sqlStateError struct {
+	code string
+}
+
+func (e *sqlStateError) Error() string {
+	return "pq: restart transaction: TransactionRetryWithProtoRefreshError"
+}
+
+func (e *sqlStateError) SQLState() string {
+	return e.code
+}
+
+type fieldsError struct {
+	code string
+}
+
+func (e *fieldsError) Error() string {
+	return "pq: could not serialize access"
+}
+
+func (e *fieldsError) Get(k byte) string {
+	if k == 'C' {
+		return e.code
+	}
+	return ""
+}
+
+func TestIsRetryable_CockroachDb(t *testing.T) {
+
+	d, err := getDialect(COCKROACHDB)
+	if err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	retryable := []error{
+		&sqlStateError{code: "40001"},
+		fmt.Errorf("error inserting migration history: %w", &fieldsError{code: "40001"}),
+	}
+
+	for _, e := range retryable {
+		if !isRetryable(d, e) {
+			t.Errorf("expected error %v to be retryable", e)
+		}
+	}
+
+	if isRetryable(d, &fieldsError{code: "42601"}) {
+		t.Errorf("expected syntax error not to be retryable")
+	}
+
+	if isRetryable(&postgresDialect{}, &sqlStateError{code: "40001"}) {
+		t.Errorf("expected %s dialect not to retry", POSTGRES)
+	}
+}
+
+func TestCockroachDbLock(t *testing.T) {
+
+	f := newFakeExecutor()
+	f.affects("INSERT INTO", 1)
+
+	ctx := context.Background()
+	conn, err := f.Conn(ctx)
+	if err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+	defer conn.Close()
+
+	d := &cockroachDialect{}
+	if err = d.Lock(ctx, conn, "", "flyway_schema_history"); err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	if s := f.executedContaining("UPDATE"); len(s) > 0 {
+		t.Errorf("expected a free lock not to be taken over but got %q", s)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err = d.Unlock(ctx, conn, "", "flyway_schema_history"); err != nil {
+			t.Fatalf("expected nil but got error %v", err)
+		}
+	}
+
+	if s := f.executedContaining("DELETE FROM"); len(s) != 1 {
+		t.Errorf("expected the lock row to be deleted once but got %q", s)
+	}
+}
+
+func TestCockroachDbLock_StaleTakeOver(t *testing.T) {
+
+	f := newFakeExecutor()
+	f.affects("UPDATE", 1)
+
+	ctx := context.Background()
+	conn, err := f.Conn(ctx)
+	if err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+	defer conn.Close()
+
+	d := &cockroachDialect{}
+	if err = d.LockExpiring(ctx, conn, "", "flyway_schema_history", time.Minute); err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	if s := f.executedContaining("UPDATE"); len(s) != 1 {
+		t.Errorf("expected the stale lock to be taken over once but got %q", s)
+	}
+
+	if err = d.Unlock(ctx, conn, "", "flyway_schema_history"); err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	if s := f.executedContaining("DELETE FROM"); len(s) != 1 {
+		t.Errorf("expected the taken over lock to be released but got %q", s)
+	}
+}
+
+func TestCockroachDbLock_WaitTimeout(t *testing.T) {
+
+	f := newFakeExecutor()
+	conf := getFakeConfig(f)
+	conf.Driver = COCKROACHDB
+	conf.LockTimeout = 50 * time.Millisecond
+	conf.StaleLockAge = -1
+
+	_, err := Migrate(conf)
+
+	var timeout *TimeoutError
+	if !errors.As(err, &timeout) || timeout.Timeout != conf.LockTimeout {
+		t.Fatalf("expected a lock wait timeout but got %v", err)
+	}
+
+	if s := f.executedContaining("UPDATE"); len(s) > 0 {
+		t.Errorf("expected no takeover with a negative StaleLockAge but got %q", s)
+	}
+
+	if s := f.executedContaining("CREATE TABLE IF NOT EXISTS"); len(s) != 1 {
+		t.Errorf("expected only the lock table to be created but got %q", s)
+	}
+}
+
+func TestCockroachDbMigrateTx(t *testing.T) {
+
+	// an up to date schema history table, the fixtures hold schema changes committed asynchronously
+	f := newFakeExecutor()
+	f.onQuery("information_schema.tables", []string{"count"}, []driver.Value{int64(1)})
+	f.onQuery("layout_version", []string{"layout_version"}, []driver.Value{int64(historyLayoutVersion)})
+	conf := getFakeConfig(f)
+	conf.Driver = COCKROACHDB
+
+	tx, err := f.Begin()
+	if err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+	defer tx.Rollback()
+
+	if _, err = MigrateTx(conf, tx); !errors.Is(err, ErrNonTransactionalMigration) {
+		t.Errorf("expected error %v but got %v", ErrNonTransactionalMigration, err)
+	}
+
+	if s := f.executedContaining("product"); len(s) > 0 {
+		t.Errorf("expected no script statement but got %q", s)
+	}
+}
+
+func TestNonTransactional(t *testing.T) {
+
+	tests := []struct {
+		driver    Driver
+		statement string
+		expected  bool
+	}{
+		{POSTGRES, "CREATE INDEX CONCURRENTLY idx_product ON product (name)", true},
+		{POSTGRES, "create unique index concurrently idx_product ON product (name)", true},
+		{POSTGRES, "DROP INDEX CONCURRENTLY idx_product", true},
+		{POSTGRES, "REINDEX INDEX CONCURRENTLY idx_product", true},
+		{POSTGRES, "VACUUM ANALYZE product", true},
+		{POSTGRES, "ALTER SYSTEM SET work_mem = '64MB'", true},
+		{POSTGRES, "CREATE INDEX idx_product ON product (name)", false},
+		{POSTGRES, "ALTER TABLE product ADD COLUMN name TEXT", false},
+		{COCKROACHDB, "ALTER TABLE product ADD COLUMN name TEXT", true},
+		{COCKROACHDB, "UPDATE product SET name = 'x'", false},
+		{MYSQL, "CREATE TABLE product (id INT)", true},
+		{MYSQL, "TRUNCATE product", true},
+		{MYSQL, "INSERT INTO product (id) VALUES (1)", false},
+		{MARIADB, "RENAME TABLE product TO item", true},
+		{MSSQLSERVER, "ALTER DATABASE app SET READ_COMMITTED_SNAPSHOT ON", true},
+		{MSSQLSERVER, "CREATE TABLE product (id INT)", false},
+		{SQLITE3, "VACUUM", true},
+		{SQLITE3, "CREATE TABLE product (id INTEGER)", false},
+	}
+
+	for _, test := range tests {
+
+		d, err := getDialect(test.driver)
+		if err != nil {
+			t.Fatalf("expected nil but got error %v", err)
+		}
+
+		if nonTransactional := isNonTransactional(d, test.statement); nonTransactional != test.expected {
+			t.Errorf("expected %v for %s on %s but got %v", test.expected, test.statement, test.driver, nonTransactional)
+		}
+	}
+}
+
+func TestCockroachDbMigrate_SerializationConflict(t *testing.T) {
+
+	// an empty database whose lock row is free
+	newExecutor := func() *fakeExecutor {
+		f := newFakeExecutor()
+		f.onQuery("information_schema.tables", []string{"count"}, []driver.Value{int64(0)})
+		f.onQuery("SELECT current_user", []string{"current_user"}, []driver.Value{"root"})
+		f.affects("ON CONFLICT (id) DO NOTHING", 1)
+		return f
+	}
+
+	f := newExecutor()
+	f.failTimesOn("ADD COLUMN description", &sqlStateError{code: "40001"}, 2)
+
+	// serialization conflicts are retried without ScriptRetries
+	conf := getFakeConfig(f)
+	conf.Driver = COCKROACHDB
+
+	result, err := Migrate(conf)
+	if err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	if result.MigrationsExecuted != 3 {
+		t.Errorf("expected 3 migrations but got %d", result.MigrationsExecuted)
+	}
+
+	if s := f.executedContaining("ADD COLUMN description"); len(s) != 3 {
+		t.Errorf("expected the script to run 3 times but got %q", s)
+	}
+
+	// lock errors are retried only when ScriptRetries is set
+	f = newExecutor()
+	f.failTimesOn("ADD COLUMN description", &fieldsError{code: "55P03"}, 1)
+	conf.Db = f
+
+	if _, err = Migrate(conf); err == nil {
+		t.Fatalf("expected error without retries of lock errors but got nil")
+	}
+
+	if s := f.executedContaining("ADD COLUMN description"); len(s) != 1 {
+		t.Errorf("expected the script to run once but got %q", s)
+	}
+
+	// a negative ScriptRetries disables the default retries
+	f = newExecutor()
+	f.failTimesOn("ADD COLUMN description", &sqlStateError{code: "40001"}, 1)
+	conf.Db = f
+	conf.ScriptRetries = -1
+
+	if _, err = Migrate(conf); err == nil {
+		t.Fatalf("expected error with retries disabled but got nil")
+	}
+}
+
+func TestMariaDbVersion(t *testing.T) {
+
+	f := newFakeExecutor()
+	f.onQuery("VERSION()", []string{"version"}, []driver.Value{"10.11.4-MariaDB-1:10.11.4+maria~ubu2204"})
+
+	v, err := mariadbVersion(context.Background(), f)
+	if err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	if v.Major != 10 || v.Minor != 11 {
+		t.Errorf("expected MariaDB 10.11 but got %s", v.Raw)
+	}
+
+	f = newFakeExecutor()
+	f.onQuery("VERSION()", []string{"version"}, []driver.Value{"8.0.36"})
+
+	if _, err = mariadbVersion(context.Background(), f); err == nil {
+		t.Errorf("expected error for a MySQL server")
+	}
+}
+
+func TestParseServerVersion(t *testing.T) {
+
+	v, err := parseServerVersion("10.11.4-MariaDB-1:10.11.4+maria~ubu2204")
+	if err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	if v.Major != 10 || v.Minor != 11 {
+		t.Errorf("expected version 10.11 but got %d.%d", v.Major, v.Minor)
+	}
+
+	if !v.atLeast(10, 3) || v.atLeast(11, 0) {
+		t.Errorf("unexpected version comparison for %s", v.Raw)
+	}
+
+	if _, err := parseServerVersion("MariaDB"); err == nil {
+		t.Errorf("expected error for unrecognized version")
+	}
+}
+
+func TestMaintenanceDSN(t *testing.T) {
+
+	tests := []struct {
+		name        string
+		dialect     DatabaseCreator
+		dsn         string
+		maintenance string
+		database    string
+	}{
+		{"postgres url", &postgresDialect{}, "postgres://u:p@localhost:5432/app?sslmode=disable",
+			"postgres://u:p@localhost:5432/postgres?sslmode=disable", "app"},
+		{"postgres keyword/value", &postgresDialect{}, "host=localhost dbname='my app' user=u",
+			"host=localhost dbname=postgres user=u", "my app"},
+		{"cockroachdb", &cockroachDialect{}, "postgresql://root@localhost:26257/app",
+			"postgresql://root@localhost:26257/defaultdb", "app"},
+		{"mysql", &mysqlDialect{}, "u:p/w@tcp(localhost:3306)/app?parseTime=true",
+			"u:p/w@tcp(localhost:3306)/information_schema?parseTime=true", "app"},
+		{"sqlserver url", &sqlServerDialect{}, "sqlserver://sa:p@localhost:1433?database=app",
+			"sqlserver://sa:p@localhost:1433?database=master", "app"},
+		{"sqlserver ado", &sqlServerDialect{}, "server=localhost;Initial Catalog=app;user id=sa",
+			"server=localhost;Initial Catalog=master;user id=sa", "app"},
+	}
+
+	for _, tt := range tests {
+
+		maintenance, database, err := tt.dialect.MaintenanceDSN(tt.dsn)
+		if err != nil {
+			t.Fatalf("%s: expected nil but got error %v", tt.name, err)
+		}
+
+		if maintenance != tt.maintenance || database != tt.database {
+			t.Errorf("%s: expected %q and %q but got %q and %q", tt.name, tt.maintenance, tt.database, maintenance, database)
+		}
+	}
+
+	if _, _, err := (&mysqlDialect{}).MaintenanceDSN("u:p@tcp(localhost:3306)/"); err == nil {
+		t.Errorf("expected error for connection string without database")
+	}
+}
+
+func TestCreateDatabaseCommand(t *testing.T) {
+
+	tests := []struct {
+		name      string
+		dialect   DatabaseCreator
+		encoding  string
+		collation string
+		expected  string
+	}{
+		{"postgres", &postgresDialect{}, "", "", `CREATE DATABASE "app"`},
+		{"postgres options", &postgresDialect{}, "UTF8", "en_US.UTF-8",
+			`CREATE DATABASE "app" TEMPLATE template0 ENCODING 'UTF8' LC_COLLATE 'en_US.UTF-8' LC_CTYPE 'en_US.UTF-8'`},
+		{"postgres quoted options", &postgresDialect{}, "UTF'8", "en'US",
+			`CREATE DATABASE "app" TEMPLATE template0 ENCODING 'UTF''8' LC_COLLATE 'en''US' LC_CTYPE 'en''US'`},
+		{"cockroachdb", &cockroachDialect{}, "UTF8", "", `CREATE DATABASE IF NOT EXISTS "app" ENCODING = 'UTF8'`},
+		{"cockroachdb quoted encoding", &cockroachDialect{}, "UTF'8", "", `CREATE DATABASE IF NOT EXISTS "app" ENCODING = 'UTF''8'`},
+		{"mysql", &mysqlDialect{}, "utf8mb4", "utf8mb4_unicode_ci",
+			"CREATE DATABASE IF NOT EXISTS `app` CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci"},
+		{"sqlserver", &sqlServerDialect{}, "", "Latin1_General_100_CI_AS_SC_UTF8",
+			"CREATE DATABASE [app] COLLATE Latin1_General_100_CI_AS_SC_UTF8"},
+	}
+
+	for _, tt := range tests {
+
+		command, err := tt.dialect.CreateDatabase("app", tt.encoding, tt.collation)
+		if err != nil {
+			t.Fatalf("%s: expected nil but got error %v", tt.name, err)
+		}
+
+		if command != tt.expected {
+			t.Errorf("%s: expected %q but got %q", tt.name, tt.expected, command)
+		}
+	}
+
+	if _, err := (&sqlServerDialect{}).CreateDatabase("app", "UTF8", ""); err == nil {
+		t.Errorf("expected error for encoding on %s", MSSQLSERVER)
+	}
+}
+
+func TestSessionTimeouts(t *testing.T) {
+
+	tests := []struct {
+		name      string
+		dialect   SessionTimeoutDialect
+		lock      time.Duration
+		statement time.Duration
+		expected  []string
+	}{
+		{"postgres", &postgresDialect{}, 5 * time.Second, time.Minute,
+			[]string{"SET lock_timeout = 5000", "SET statement_timeout = 60000"}},
+		{"cockroachdb", &cockroachDialect{}, 0, 1500 * time.Millisecond, []string{"SET statement_timeout = 1500"}},
+		{"mysql", &mysqlDialect{}, 1500 * time.Millisecond, 30 * time.Second,
+			[]string{"SET SESSION lock_wait_timeout = 2", "SET SESSION innodb_lock_wait_timeout = 2",
+				"SET SESSION max_execution_time = 30000"}},
+		{"mariadb", &mariadbDialect{}, 0, 1500 * time.Millisecond, []string{"SET SESSION max_statement_time = 1.5"}},
+		{"sqlserver", &sqlServerDialect{}, 3 * time.Second, 0, []string{"SET LOCK_TIMEOUT 3000"}},
+		{"sqlite3", &sqlite3Dialect{}, 3 * time.Second, 0, []string{"PRAGMA busy_timeout = 3000"}},
+	}
+
+	for _, tt := range tests {
+
+		commands, err := tt.dialect.SessionTimeouts(tt.lock, tt.statement)
+		if err != nil {
+			t.Fatalf("%s: expected nil but got error %v", tt.name, err)
+		}
+
+		if !reflect.DeepEqual(commands, tt.expected) {
+			t.Errorf("%s: expected %q but got %q", tt.name, tt.expected, commands)
+		}
+	}
+
+	for _, d := range []SessionTimeoutDialect{&sqlServerDialect{}, &sqlite3Dialect{}} {
+		if _, err := d.SessionTimeouts(0, time.Second); !errors.Is(err, ErrSessionTimeoutNotSupported) {
+			t.Errorf("expected error %v for statement timeout on %T but got %v", ErrSessionTimeoutNotSupported, d, err)
+		}
+	}
+}
+
+type timeoutCodeError struct {
+	state   string
+	number  int32
+	message string
+}
+
+func (e *timeoutCodeError) Error() string {
+	return e.message
+}
+
+func (e *timeoutCodeError) SQLState() string {
+	return e.state
+}
+
+func (e *timeoutCodeError) SQLErrorNumber() int32 {
+	return e.number
+}
+
+func TestExceededTimeout(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		dialect SessionTimeoutDialect
+		err     error
+		setting string
+		lock    bool
+	}{
+		{"postgres lock", &postgresDialect{},
+			&timeoutCodeError{state: "55P03", message: "canceling statement due to lock timeout"}, "lock_timeout", true},
+		{"postgres statement", &postgresDialect{},
+			&timeoutCodeError{state: "57014", message: "canceling statement due to statement timeout"}, "statement_timeout", false},
+		{"postgres user cancel", &postgresDialect{},
+			&timeoutCodeError{state: "57014", message: "canceling statement due to user request"}, "", false},
+		{"mysql lock", &mysqlDialect{},
+			errors.New("Error 1205 (HY000): Lock wait timeout exceeded; try restarting transaction"), "lock_wait_timeout", true},
+		{"mysql statement", &mysqlDialect{},
+			errors.New("Error 3024 (HY000): Query execution was interrupted, maximum statement execution time exceeded"),
+			"max_execution_time", false},
+		{"mariadb statement", &mariadbDialect{},
+			errors.New("Error 1969 (70100): Query execution was interrupted (max_statement_time exceeded)"),
+			"max_statement_time", false},
+		{"mysql other", &mysqlDialect{}, errors.New("Error 1064 (42000): You have an error in your SQL syntax"), "", false},
+		{"sqlserver lock", &sqlServerDialect{},
+			&timeoutCodeError{number: 1222, message: "mssql: Lock request time out period exceeded."}, "LOCK_TIMEOUT", true},
+		{"sqlite3 busy", &sqlite3Dialect{}, errors.New("database is locked (5) (SQLITE_BUSY)"), "busy_timeout", true},
+	}
+
+	for _, tt := range tests {
+
+		setting, lock := tt.dialect.ExceededTimeout(fmt.Errorf("wrapped: %w", tt.err))
+		if setting != tt.setting || lock != tt.lock {
+			t.Errorf("%s: expected %q %v but got %q %v", tt.name, tt.setting, tt.lock, setting, lock)
+		}
+	}
+}
+
+func TestIsRetryable_LockErrors(t *testing.T) {
+
+	tests := []struct {
+		name      string
+		dialect   Dialect
+		err       error
+		retryable bool
+	}{
+		{"postgres deadlock", &postgresDialect{}, &timeoutCodeError{state: "40P01"}, true},
+		{"postgres lock timeout", &postgresDialect{}, &timeoutCodeError{state: "55P03"}, true},
+		{"postgres syntax", &postgresDialect{}, &timeoutCodeError{state: "42601"}, false},
+		{"cockroachdb deadlock", &cockroachDialect{}, &timeoutCodeError{state: "40P01"}, true},
+		{"mysql lock wait timeout", &mysqlDialect{},
+			errors.New("Error 1205 (HY000): Lock wait timeout exceeded; try restarting transaction"), true},
+		{"mariadb deadlock", &mariadbDialect{},
+			errors.New("Error 1213 (40001): Deadlock found when trying to get lock; try restarting transaction"), true},
+		{"mysql duplicate column", &mysqlDialect{}, errors.New("Error 1060 (42S21): Duplicate column name 'sku'"), false},
+		{"sqlserver deadlock", &sqlServerDialect{}, &timeoutCodeError{number: 1205}, true},
+		{"sqlserver lock timeout", &sqlServerDialect{}, &timeoutCodeError{number: 1222}, true},
+		{"sqlserver invalid column", &sqlServerDialect{}, &timeoutCodeError{number: 207}, false},
+		{"sqlite3 busy", &sqlite3Dialect{}, errors.New("database is locked (5) (SQLITE_BUSY)"), true},
+	}
+
+	for _, tt := range tests {
+		if retryable := isRetryable(tt.dialect, fmt.Errorf("wrapped: %w", tt.err)); retryable != tt.retryable {
+			t.Errorf("%s: expected retryable %v but got %v", tt.name, tt.retryable, retryable)
+		}
+	}
+}
@@ -1,12 +1,43 @@
 package goflyway
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	ErrDatabaseConnectionNull     = errors.New("database connection is null")
+	ErrDatabaseConnection         = errors.New("cannot connect to database")
+	ErrUnsupportedDatabaseDriver  = errors.New("unsupported database driver")
+	ErrRunnerNotInitialized       = errors.New("runner not initialized")
+	ErrLocationCannotBeEmpty      = errors.New("migration location cannot be empty")
+	ErrCreateSchemasNotSupported  = errors.New("creating schemas is not supported by database driver")
+	ErrCreateDatabaseNotSupported = errors.New("creating the database is not supported")
+	ErrSessionTimeoutNotSupported = errors.New("session timeout is not supported by database driver")
+	ErrCleanDisabled              = errors.New("clean is disabled")
+	ErrLintFailed                 = errors.New("migration lint found errors")
+
+	ErrFlywayCompatibilityNotSupported = errors.New("flyway compatibility is not supported by database driver")
+	ErrUnsupportedChecksumAlgorithm    = errors.New("unsupported checksum algorithm")
+	ErrRepairNotSupported              = errors.New("repair is not supported by database driver")
+	ErrBaselineNotEmpty                = errors.New("cannot baseline a schema history table with applied migrations")
+	ErrInvalidMigrationName            = errors.New("invalid migration name")
+	ErrSessionNotSupported             = errors.New("executor does not provide a database session")
+	ErrNonTransactionalMigration       = errors.New("migration cannot run inside a transaction")
+)
 
+// Sentinel values matched by the typed migration errors through errors.Is
 var (
-	ErrDatabaseConnectionNull    = errors.New("database connection is null")
-	ErrUnsupportedDatabaseDriver = errors.New("unsupported database driver")
-	ErrRunnerNotInitialized      = errors.New("runner not initialized")
-	ErrLocationCannotBeEmpty     = errors.New("migration location cannot be empty")
+	ErrChecksumMismatch    = errors.New("migration checksum mismatch")
+	ErrDuplicateVersion    = errors.New("duplicated migration version")
+	ErrDescriptionMismatch = errors.New("migration description mismatch")
+	ErrOutOfOrder          = errors.New("migration out of order")
+	ErrMissingMigration    = errors.New("applied migration not resolved locally")
+	ErrScriptExecution     = errors.New("migration script execution failed")
+	ErrInvalidConfig       = errors.New("invalid configuration")
+	ErrTimeout             = errors.New("migration timeout exceeded")
 )
 
 var (
@@ -15,16 +46,175 @@ var (
 
 type ErrMigration struct {
 	message string
+	err     error
 }
 
 func (e *ErrMigration) Error() string {
 	return e.message
 }
 
+// Unwrap returns the error that caused the migration failure
+func (e *ErrMigration) Unwrap() error {
+	return e.err
+}
+
 func throwErrMigration(err error) error {
 	e := ErrMigration{
 		message: err.Error(),
+		err:     err,
 	}
 
 	return &e
 }
+
+// ChecksumMismatchError is returned when the checksum of an applied migration differs from the local script
+type ChecksumMismatchError struct {
+	Version string
+	Script  string
+
+	// Checksum applied to database
+	Expected string
+
+	// Checksum resolved locally
+	Actual string
+}
+
+func (e *ChecksumMismatchError) Error() string {
+	return fmt.Sprintf("migration checksum mismatch for migration version %s: applied to database = %s, resolved locally = %s",
+		e.Version, e.Expected, e.Actual)
+}
+
+func (e *ChecksumMismatchError) Is(target error) bool {
+	return target == ErrChecksumMismatch
+}
+
+// DuplicateVersionError is returned when more than one local script has the same version
+type DuplicateVersionError struct {
+	Version string
+	Scripts []string
+}
+
+func (e *DuplicateVersionError) Error() string {
+	return fmt.Sprintf("found more than one migration with version %s: %s", e.Version, strings.Join(e.Scripts, " "))
+}
+
+func (e *DuplicateVersionError) Is(target error) bool {
+	return target == ErrDuplicateVersion
+}
+
+// DescriptionMismatchError is returned when the description of an applied migration differs from the local script
+type DescriptionMismatchError struct {
+	Version string
+	Script  string
+
+	// Description applied to database
+	Expected string
+
+	// Description resolved locally
+	Actual string
+}
+
+func (e *DescriptionMismatchError) Error() string {
+	return fmt.Sprintf("migration description mismatch for migration version %s: applied to database = %s, resolved locally = %s",
+		e.Version, e.Expected, e.Actual)
+}
+
+func (e *DescriptionMismatchError) Is(target error) bool {
+	return target == ErrDescriptionMismatch
+}
+
+// OutOfOrderError is returned when a local script is older than migrations already applied and OutOfOrder is disabled
+type OutOfOrderError struct {
+	Version string
+	Script  string
+}
+
+func (e *OutOfOrderError) Error() string {
+	return fmt.Sprintf("detected resolved migration not applied to database: %s, to allow executing this migration, set OutOfOrder=true",
+		e.Version)
+}
+
+func (e *OutOfOrderError) Is(target error) bool {
+	return target == ErrOutOfOrder
+}
+
+// MissingMigrationError is returned when an applied migration has no local script and IgnoreMissingMigrations is disabled
+type MissingMigrationError struct {
+	Version string
+	Script  string
+}
+
+func (e *MissingMigrationError) Error() string {
+	return fmt.Sprintf("detected applied migration not resolved locally: %s", e.Version)
+}
+
+func (e *MissingMigrationError) Is(target error) bool {
+	return target == ErrMissingMigration
+}
+
+// ScriptExecutionError is returned when a migration script or its history record fails to execute.
+// Err holds the error returned by the database driver
+type ScriptExecutionError struct {
+	Version string
+	Script  string
+	Err     error
+}
+
+func (e *ScriptExecutionError) Error() string {
+	return fmt.Sprintf("migration %s failed: %v", e.Script, e.Err)
+}
+
+func (e *ScriptExecutionError) Is(target error) bool {
+	return target == ErrScriptExecution
+}
+
+func (e *ScriptExecutionError) Unwrap() error {
+	return e.Err
+}
+
+// TimeoutError is returned as the Err of ScriptExecutionError when a script exceeds ScriptTimeout, its timeout
+// directive or a session timeout of the dialect such as lock_timeout
+type TimeoutError struct {
+	// "script timeout" or the name of the session setting
+	Setting string
+
+	// Timeout exceeded, zero when the session setting was not set by GoFlyway
+	Timeout time.Duration
+
+	Err error
+}
+
+func (e *TimeoutError) Error() string {
+
+	if e.Timeout <= 0 {
+		return fmt.Sprintf("%s exceeded: %v", e.Setting, e.Err)
+	}
+
+	return fmt.Sprintf("%s of %s exceeded: %v", e.Setting, e.Timeout, e.Err)
+}
+
+func (e *TimeoutError) Is(target error) bool {
+	return target == ErrTimeout
+}
+
+func (e *TimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// ConnectionError is returned when the database of DSN cannot be opened or does not accept connections after
+// ConnectRetries. Err holds the error returned by the database driver
+type ConnectionError struct {
+	Err error
+}
+
+func (e *ConnectionError) Error() string {
+	return fmt.Sprintf("%v: %v", ErrDatabaseConnection, e.Err)
+}
+
+func (e *ConnectionError) Is(target error) bool {
+	return target == ErrDatabaseConnection
+}
+
+func (e *ConnectionError) Unwrap() error {
+	return e.Err
+}
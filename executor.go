@@ -0,0 +1,111 @@
+package goflyway
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// Executor runs the statements of GoFlyway against a database. *sql.DB and *sql.Conn implement it, a *sql.Conn runs
+// the whole operation on its session
+type Executor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+// txExecutor runs the statements of MigrateTx in the transaction of the caller, it does not start transactions
+type txExecutor struct {
+	*sql.Tx
+}
+
+func (e txExecutor) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	return nil, fmt.Errorf("%w: a transaction of the caller is in progress", ErrNonTransactionalMigration)
+}
+
+// migrationTx is a transaction started for a script or a schema history row, or the transaction of the caller of
+// MigrateTx, which is neither committed nor rolled back
+type migrationTx struct {
+	*sql.Tx
+	owned bool
+}
+
+func (t migrationTx) Commit() error {
+
+	if !t.owned {
+		return nil
+	}
+
+	return t.Tx.Commit()
+}
+
+func (t migrationTx) Rollback() error {
+
+	if !t.owned {
+		return nil
+	}
+
+	return t.Tx.Rollback()
+}
+
+// beginTx Start a transaction on db, or join the transaction of the caller of MigrateTx
+func (g *goFlywayRunner) beginTx(ctx context.Context, db Executor) (migrationTx, error) {
+
+	if g.tx != nil {
+		return migrationTx{Tx: g.tx}, nil
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return migrationTx{}, err
+	}
+
+	return migrationTx{Tx: tx, owned: true}, nil
+}
+
+// connProvider is implemented by executors that hand out dedicated connections, such as *sql.DB
+type connProvider interface {
+	Conn(ctx context.Context) (*sql.Conn, error)
+}
+
+// queryRow Run a query expected to return a single row and scan its columns into dest.
+// sql.ErrNoRows is returned when the query has no rows
+func queryRow(ctx context.Context, db Executor, query string, args []interface{}, dest ...interface{}) error {
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err = rows.Err(); err != nil {
+			return err
+		}
+		return sql.ErrNoRows
+	}
+
+	if err = rows.Scan(dest...); err != nil {
+		return err
+	}
+
+	return rows.Close()
+}
+
+// sessionConn Returns the connection that holds the session of the migration lock and Clean: a dedicated
+// connection of the pool, or the *sql.Conn itself. release closes only the connections taken from the pool
+func sessionConn(ctx context.Context, db Executor) (conn *sql.Conn, release func(), err error) {
+
+	switch e := db.(type) {
+	case *sql.Conn:
+		return e, func() {}, nil
+	case connProvider:
+		conn, err = e.Conn(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		return conn, func() { conn.Close() }, nil
+	}
+
+	return nil, nil, fmt.Errorf("%w: %T", ErrSessionNotSupported, db)
+}
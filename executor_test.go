@@ -0,0 +1,536 @@
+package goflyway
+
+import (
+	"database/sql/driver"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func getFakeConfig(f *fakeExecutor) GoFlywayConfig {
+	return GoFlywayConfig{
+		Db:       f,
+		Driver:   SQLITE3,
+		Location: getWorkPath() + "/utils/test/db/migration/sqlite3",
+	}
+}
+
+func TestFakeExecutorMigrate(t *testing.T) {
+
+	f := newFakeExecutor()
+	conf := getFakeConfig(f)
+
+	result, err := Migrate(conf)
+	if err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	if result.MigrationsExecuted != 3 {
+		t.Fatalf("expected 3 migrations but got %d", result.MigrationsExecuted)
+	}
+
+	inserts := f.executedContaining((&sqlite3Dialect{}).InsertHistory("", tableName))
+	if len(inserts) != 3 {
+		t.Fatalf("expected 3 schema history inserts but got %q", inserts)
+	}
+
+	// each script and its schema history row are committed in their own transactions
+	statements := f.executed()
+	for i, s := range statements {
+
+		if !strings.HasPrefix(s, "CREATE TABLE IF NOT EXISTS product") {
+			continue
+		}
+
+		if i < 1 || len(statements) < i+5 {
+			t.Fatalf("expected the script inside a transaction followed by the history insert but got %q", statements)
+		}
+
+		expected := []string{"BEGIN", s, "COMMIT", "BEGIN", inserts[0], "COMMIT"}
+		for j, e := range expected {
+			if statements[i-1+j] != e {
+				t.Errorf("expected statement %q but got %q", e, statements[i-1+j])
+			}
+		}
+	}
+}
+
+func TestFakeExecutorScriptFailure(t *testing.T) {
+
+	f := newFakeExecutor()
+	conf := getFakeConfig(f)
+
+	f.failOn("ADD COLUMN description", errors.New("duplicate column name: description"))
+
+	result, err := Migrate(conf)
+
+	var scriptErr *ScriptExecutionError
+	if !errors.As(err, &scriptErr) || scriptErr.Version != "2" {
+		t.Fatalf("expected script execution error on version 2 but got %v", err)
+	}
+
+	if result.MigrationsExecuted != 1 {
+		t.Errorf("expected 1 migration before the failure but got %d", result.MigrationsExecuted)
+	}
+
+	if inserts := f.executedContaining((&sqlite3Dialect{}).InsertHistory("", tableName)); len(inserts) != 1 {
+		t.Errorf("expected 1 schema history insert but got %q", inserts)
+	}
+
+	statements := f.executed()
+	if statements[len(statements)-1] != "ROLLBACK" {
+		t.Errorf("expected the failed script to be rolled back but got %q", statements)
+	}
+}
+
+func TestFakeExecutorAppliedMigrations(t *testing.T) {
+
+	f := newFakeExecutor()
+	conf := getFakeConfig(f)
+
+	checksum, err := CalculateChecksum(conf.Location + "/V1__test_create_table_product.sql")
+	if err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	f.onQuery((&sqlite3Dialect{}).SelectHistory("", tableName),
+		[]string{"installed_rank", "version", "description", "type", "script", "checksum", "installed_by",
+			"installed_on", "execution_time", "success"},
+		[]driver.Value{int64(1), "1", "test create table product", "SQL", "V1__test_create_table_product.sql",
+			checksum, "tester", time.Now(), int64(3), true})
+
+	result, err := Migrate(conf)
+	if err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	if result.InitialSchemaVersion != "1" || result.MigrationsExecuted != 2 {
+		t.Errorf("expected 2 migrations from version 1 but got %d from %q",
+			result.MigrationsExecuted, result.InitialSchemaVersion)
+	}
+
+	if s := f.executedContaining("CREATE TABLE IF NOT EXISTS product"); len(s) != 0 {
+		t.Errorf("expected applied migration to be skipped but got %q", s)
+	}
+}
+
+func TestFakeExecutorInfo_ReadOnly(t *testing.T) {
+
+	f := newFakeExecutor()
+	conf := getFakeConfig(f)
+	f.failOn("WHERE 1 = 0", errors.New("no such table: goflyway_schema_history"))
+
+	infos, err := Info(conf)
+	if err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	for _, info := range infos {
+		if info.State != StatePending {
+			t.Errorf("expected migration %s to be pending but got %s", info.Version, info.State)
+		}
+	}
+
+	if s := f.executed(); len(s) != 1 {
+		t.Errorf("expected only the history table probe but got %q", s)
+	}
+}
+
+type execOnly struct {
+	Executor
+}
+
+func TestClean_SessionNotSupported(t *testing.T) {
+
+	conf := getFakeConfig(newFakeExecutor())
+	conf.Db = execOnly{conf.Db}
+	conf.CleanEnabled = true
+
+	if err := Clean(conf); !errors.Is(err, ErrSessionNotSupported) {
+		t.Errorf("expected error %v but got %v", ErrSessionNotSupported, err)
+	}
+}
+
+func TestFakeExecutorMigrateTx(t *testing.T) {
+
+	f := newFakeExecutor()
+	conf := getFakeConfig(f)
+
+	tx, err := f.Begin()
+	if err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+	defer tx.Rollback()
+
+	result, err := MigrateTx(conf, tx)
+	if err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	if result.MigrationsExecuted != 3 {
+		t.Errorf("expected 3 migrations but got %d", result.MigrationsExecuted)
+	}
+
+	// the transaction of the caller is the only one, and is left open
+	for _, s := range []string{"BEGIN", "COMMIT", "ROLLBACK"} {
+		if found := f.executedContaining(s); len(found) > 1 || (s != "BEGIN" && len(found) > 0) {
+			t.Errorf("expected no transaction other than the caller one but got %q", found)
+		}
+	}
+}
+
+func TestMigrateTx_NonTransactionalDDL(t *testing.T) {
+
+	// a MySQL schema history table with the current layout, the fixtures create tables
+	f := newFakeExecutor()
+	f.onQuery("layout_version", []string{"layout_version"}, []driver.Value{int64(historyLayoutVersion)})
+	conf := getFakeConfig(f)
+	conf.Driver = MYSQL
+
+	tx, err := f.Begin()
+	if err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+	defer tx.Rollback()
+
+	_, err = MigrateTx(conf, tx)
+	if !errors.Is(err, ErrNonTransactionalMigration) || !strings.Contains(err.Error(), "V1__test_create_table_product.sql line 1") {
+		t.Errorf("expected error %v naming the first script but got %v", ErrNonTransactionalMigration, err)
+	}
+
+	if s := f.executedContaining("CREATE TABLE"); len(s) > 0 {
+		t.Errorf("expected no DDL statement in the transaction but got %q", s)
+	}
+
+	// scripts without DDL are applied
+	f = newFakeExecutor()
+	f.onQuery("layout_version", []string{"layout_version"}, []driver.Value{int64(historyLayoutVersion)})
+	f.onQuery("CURRENT_USER()", []string{"user"}, []driver.Value{"app@%"})
+	conf = getFakeConfig(f)
+	conf.Driver = MYSQL
+	conf.Location = t.TempDir()
+
+	if err = os.WriteFile(conf.Location+"/V1__seed.sql", []byte("INSERT INTO category (id) VALUES (1);\n"), 0644); err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	tx, err = f.Begin()
+	if err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+	defer tx.Rollback()
+
+	if _, err = MigrateTx(conf, tx); err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	if s := f.executedContaining("INSERT INTO category"); len(s) != 1 {
+		t.Errorf("expected the script to be applied but got %q", f.executed())
+	}
+
+	// a missing schema history table would be created by a DDL statement
+	f = newFakeExecutor()
+	f.failOn("WHERE 1 = 0", errors.New("table goflyway_schema_history doesn't exist"))
+	conf = getFakeConfig(f)
+	conf.Driver = MYSQL
+
+	tx, err = f.Begin()
+	if err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+	defer tx.Rollback()
+
+	if _, err = MigrateTx(conf, tx); !errors.Is(err, ErrNonTransactionalMigration) {
+		t.Errorf("expected error %v but got %v", ErrNonTransactionalMigration, err)
+	}
+
+	if s := f.executedContaining("CREATE TABLE"); len(s) > 0 {
+		t.Errorf("expected no DDL statement in the transaction but got %q", s)
+	}
+}
+
+func TestMigrateTx_ConcurrentIndex(t *testing.T) {
+
+	f := newFakeExecutor()
+	f.onQuery("information_schema.tables", []string{"count"}, []driver.Value{int64(0)})
+	f.onQuery("SELECT current_user", []string{"current_user"}, []driver.Value{"app"})
+	conf := getFakeConfig(f)
+	conf.Driver = POSTGRES
+	conf.Location = t.TempDir()
+
+	scripts := map[string]string{
+		"V1__category.sql":       "CREATE TABLE category (id INTEGER);\n",
+		"V2__category_index.sql": "-- built without blocking writes\nCREATE INDEX CONCURRENTLY idx_category ON category (id);\n",
+	}
+	for name, script := range scripts {
+		if err := os.WriteFile(conf.Location+"/"+name, []byte(script), 0644); err != nil {
+			t.Fatalf("expected nil but got error %v", err)
+		}
+	}
+
+	tx, err := f.Begin()
+	if err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+	defer tx.Rollback()
+
+	_, err = MigrateTx(conf, tx)
+	if !errors.Is(err, ErrNonTransactionalMigration) || !strings.Contains(err.Error(), "V2__category_index.sql line 2") {
+		t.Errorf("expected error %v naming the script but got %v", ErrNonTransactionalMigration, err)
+	}
+
+	// no script runs before the refusal
+	if s := f.executedContaining("category"); len(s) > 0 {
+		t.Errorf("expected no script statement but got %q", s)
+	}
+}
+
+func TestMigrateTx_RestoresSearchPath(t *testing.T) {
+
+	f := newFakeExecutor()
+	f.onQuery("information_schema.tables", []string{"count"}, []driver.Value{int64(0)})
+	f.onQuery("SELECT current_user", []string{"current_user"}, []driver.Value{"app"})
+	f.onQuery("current_setting('search_path')", []string{"search_path"}, []driver.Value{`"$user", public`})
+	conf := getFakeConfig(f)
+	conf.Driver = POSTGRES
+	conf.DefaultSchema = "tenant"
+
+	tx, err := f.Begin()
+	if err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+	defer tx.Rollback()
+
+	if _, err = MigrateTx(conf, tx); err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	statements := f.executed()
+	if last := statements[len(statements)-1]; !strings.Contains(last, "set_config('search_path'") {
+		t.Errorf("expected the search path to be restored last but got %q", statements)
+	}
+
+	if s := f.executedContaining("SET LOCAL search_path"); len(s) != 3 {
+		t.Errorf("expected the search path to be set for the 3 scripts but got %q", s)
+	}
+}
+
+func TestFakeExecutorInitSQL(t *testing.T) {
+
+	f := newFakeExecutor()
+	conf := getFakeConfig(f)
+	conf.InitSQL = "PRAGMA recursive_triggers = ON"
+
+	if _, err := Migrate(conf); err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	statements := f.executed()
+	if statements[0] != conf.InitSQL || len(f.executedContaining(conf.InitSQL)) != 1 {
+		t.Errorf("expected InitSQL to run once before any other statement but got %q", statements)
+	}
+
+	f.failOn(conf.InitSQL, errors.New("no such pragma"))
+
+	if _, err := Migrate(conf); err == nil || !strings.Contains(err.Error(), "no such pragma") {
+		t.Errorf("expected init SQL error but got %v", err)
+	}
+}
+
+func TestFakeExecutorPlaceholders(t *testing.T) {
+
+	f := newFakeExecutor()
+	conf := getFakeConfig(f)
+	conf.Location = t.TempDir()
+	conf.Placeholders = map[string]string{"owner": "admin"}
+
+	script := "CREATE TABLE category (id INTEGER);\nGRANT SELECT ON category TO ${owner}, ${reader};\n"
+	if err := os.WriteFile(conf.Location+"/V1__category.sql", []byte(script), 0644); err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	if _, err := Migrate(conf); err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	// placeholders without value are kept as is
+	if s := f.executedContaining("GRANT SELECT ON category TO admin, ${reader};"); len(s) != 1 {
+		t.Errorf("expected the placeholders to be replaced but got %q", f.executed())
+	}
+}
+
+func TestFakeExecutorScriptTimeout(t *testing.T) {
+
+	f := newFakeExecutor()
+	conf := getFakeConfig(f)
+	conf.ScriptTimeout = 50 * time.Millisecond
+
+	f.blockOn("ADD COLUMN description")
+
+	result, err := Migrate(conf)
+	if !errors.Is(err, ErrTimeout) {
+		t.Fatalf("expected error %v but got %v", ErrTimeout, err)
+	}
+
+	var timeoutErr *TimeoutError
+	if !errors.As(err, &timeoutErr) || timeoutErr.Setting != "script timeout" || timeoutErr.Timeout != conf.ScriptTimeout {
+		t.Errorf("expected script timeout of %s but got %v", conf.ScriptTimeout, err)
+	}
+
+	if result.MigrationsExecuted != 1 {
+		t.Errorf("expected 1 migration before the timeout but got %d", result.MigrationsExecuted)
+	}
+}
+
+func TestFakeExecutorTimeoutDirective(t *testing.T) {
+
+	f := newFakeExecutor()
+	conf := getFakeConfig(f)
+	conf.Location = t.TempDir()
+
+	script := "-- goflyway:timeout=50ms\nCREATE INDEX idx_product_name ON product (name);\n"
+	if err := os.WriteFile(conf.Location+"/V1__index_product.sql", []byte(script), 0644); err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	f.blockOn("CREATE INDEX")
+
+	if _, err := Migrate(conf); !errors.Is(err, ErrTimeout) {
+		t.Fatalf("expected error %v but got %v", ErrTimeout, err)
+	}
+
+	script = "-- goflyway:timeout=soon\nCREATE INDEX idx_product_name ON product (name);\n"
+	if err := os.WriteFile(conf.Location+"/V1__index_product.sql", []byte(script), 0644); err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	if _, err := Migrate(conf); err == nil || !strings.Contains(err.Error(), "invalid timeout directive") {
+		t.Errorf("expected invalid timeout directive error but got %v", err)
+	}
+}
+
+func TestFakeExecutorSessionTimeouts(t *testing.T) {
+
+	f := newFakeExecutor()
+	conf := getFakeConfig(f)
+	conf.Driver = POSTGRES
+	conf.Location = getWorkPath() + "/utils/test/db/migration/postgres"
+	conf.InstalledBy = "tester"
+	conf.LockTimeout = 5 * time.Second
+
+	f.onQuery("information_schema.tables", []string{"count"}, []driver.Value{int64(0)})
+
+	if _, err := Migrate(conf); err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	lock, timeout, script := -1, -1, -1
+	for i, s := range f.executed() {
+		switch {
+		case strings.Contains(s, "pg_advisory_lock") && lock < 0:
+			lock = i
+		case s == "SET lock_timeout = 5000" && timeout < 0:
+			timeout = i
+		case strings.Contains(s, "CREATE TABLE") && strings.Contains(s, "product") && script < 0:
+			script = i
+		}
+	}
+
+	// waiting for the migration lock of another run is not bounded by the lock timeout
+	if lock < 0 || timeout < lock || script < timeout {
+		t.Errorf("expected lock_timeout set after the migration lock and before the scripts but got %q", f.executed())
+	}
+
+	conf.Driver = SQLITE3
+	conf.Location = getWorkPath() + "/utils/test/db/migration/sqlite3"
+	conf.StatementTimeout = time.Minute
+
+	if _, err := Migrate(conf); !errors.Is(err, ErrSessionTimeoutNotSupported) {
+		t.Errorf("expected error %v but got %v", ErrSessionTimeoutNotSupported, err)
+	}
+}
+
+func TestFakeExecutorScriptRetries(t *testing.T) {
+
+	f := newFakeExecutor()
+	conf := getFakeConfig(f)
+	conf.ScriptRetries = 2
+	conf.ScriptRetriesInterval = time.Millisecond
+
+	f.failTimesOn("ADD COLUMN description", errors.New("database is locked (5) (SQLITE_BUSY)"), 2)
+
+	result, err := Migrate(conf)
+	if err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	if result.MigrationsExecuted != 3 {
+		t.Errorf("expected 3 migrations but got %d", result.MigrationsExecuted)
+	}
+
+	// the script is retried from scratch in a new transaction
+	if s := f.executedContaining("ADD COLUMN description"); len(s) != 3 {
+		t.Errorf("expected the script to run 3 times but got %q", s)
+	}
+
+	if inserts := f.executedContaining((&sqlite3Dialect{}).InsertHistory("", tableName)); len(inserts) != 3 {
+		t.Errorf("expected 3 schema history inserts but got %q", inserts)
+	}
+
+	f = newFakeExecutor()
+	conf.Db = f
+	conf.ScriptRetries = 0
+
+	f.failTimesOn("ADD COLUMN description", errors.New("database is locked (5) (SQLITE_BUSY)"), 1)
+
+	if _, err = Migrate(conf); err == nil {
+		t.Fatalf("expected error without retries by default but got nil")
+	}
+
+	if s := f.executedContaining("ADD COLUMN description"); len(s) != 1 {
+		t.Errorf("expected the script to run once but got %q", s)
+	}
+}
+
+// nonTransactionalDialect commits each statement of the scripts, like MySQL DDL
+type nonTransactionalDialect struct {
+	sqlite3Dialect
+}
+
+func (d *nonTransactionalDialect) TransactionalDDL() bool {
+	return false
+}
+
+func (d *nonTransactionalDialect) SplitStatements(script string) []string {
+	return (&mysqlDialect{}).SplitStatements(script)
+}
+
+func TestFakeExecutorScriptRetries_PartiallyApplied(t *testing.T) {
+
+	RegisterDialect("sqlite3-nontransactional", &nonTransactionalDialect{})
+
+	f := newFakeExecutor()
+	conf := getFakeConfig(f)
+	conf.Driver = "sqlite3-nontransactional"
+	conf.Location = t.TempDir()
+	conf.ScriptRetries = 5
+	conf.ScriptRetriesInterval = time.Millisecond
+
+	script := "CREATE TABLE category (id INTEGER);\nINSERT INTO category (id) VALUES (1);\n"
+	if err := os.WriteFile(conf.Location+"/V1__category.sql", []byte(script), 0644); err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	f.failTimesOn("INSERT INTO category", errors.New("database is locked (5) (SQLITE_BUSY)"), 1)
+
+	if _, err := Migrate(conf); err == nil {
+		t.Fatalf("expected error but got nil")
+	}
+
+	// CREATE TABLE was committed and would fail on retry
+	if s := f.executedContaining("CREATE TABLE category"); len(s) != 1 {
+		t.Errorf("expected partially applied script not to be retried but got %q", s)
+	}
+}
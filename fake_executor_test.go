@@ -0,0 +1,295 @@
+package goflyway
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+)
+
+// fakeExecutor is an Executor backed by an in-memory database/sql driver, so migration logic can be tested without a
+// database. It records the statements it receives in order, including BEGIN, COMMIT and ROLLBACK, and answers
+// queries with the rows registered with onQuery, or no rows
+type fakeExecutor struct {
+	*sql.DB
+
+	mu         sync.Mutex
+	statements []string
+	queries    []fakeQuery
+	failures   []fakeFailure
+	blocks     []string
+	affected   []fakeAffected
+}
+
+type fakeQuery struct {
+	contains string
+	columns  []string
+	rows     [][]driver.Value
+}
+
+type fakeAffected struct {
+	contains string
+	rows     int64
+}
+
+type fakeFailure struct {
+	contains string
+	err      error
+
+	// times the statement fails, every time when zero
+	times int
+}
+
+func newFakeExecutor() *fakeExecutor {
+
+	f := &fakeExecutor{}
+	f.DB = sql.OpenDB(fakeConnector{f})
+
+	return f
+}
+
+// onQuery Answer the queries containing contains with rows
+func (f *fakeExecutor) onQuery(contains string, columns []string, rows ...[]driver.Value) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.queries = append(f.queries, fakeQuery{contains, columns, rows})
+}
+
+// failOn Fail the statements containing contains with err
+func (f *fakeExecutor) failOn(contains string, err error) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.failures = append(f.failures, fakeFailure{contains: contains, err: err})
+}
+
+// failTimesOn Fail the first times statements containing contains with err
+func (f *fakeExecutor) failTimesOn(contains string, err error, times int) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.failures = append(f.failures, fakeFailure{contains: contains, err: err, times: times})
+}
+
+// blockOn Block the statements containing contains until their context is done, as a statement waiting for a lock
+func (f *fakeExecutor) blockOn(contains string) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.blocks = append(f.blocks, contains)
+}
+
+// affects Report rows affected by the statements containing contains, instead of none
+func (f *fakeExecutor) affects(contains string, rows int64) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.affected = append(f.affected, fakeAffected{contains, rows})
+}
+
+// rowsAffected Returns the rows affected by statement registered with affects, zero otherwise
+func (f *fakeExecutor) rowsAffected(statement string) int64 {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, a := range f.affected {
+		if strings.Contains(statement, a.contains) {
+			return a.rows
+		}
+	}
+
+	return 0
+}
+
+// block Wait for ctx to be done when statement is blocked with blockOn
+func (f *fakeExecutor) block(ctx context.Context, statement string) error {
+
+	f.mu.Lock()
+	blocked := false
+	for _, contains := range f.blocks {
+		blocked = blocked || strings.Contains(statement, contains)
+	}
+	f.mu.Unlock()
+
+	if !blocked {
+		return nil
+	}
+
+	<-ctx.Done()
+
+	return ctx.Err()
+}
+
+// executed Returns the statements received so far
+func (f *fakeExecutor) executed() []string {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]string{}, f.statements...)
+}
+
+// executedContaining Returns the statements received so far that contain s
+func (f *fakeExecutor) executedContaining(s string) []string {
+
+	found := []string{}
+	for _, statement := range f.executed() {
+		if strings.Contains(statement, s) {
+			found = append(found, statement)
+		}
+	}
+
+	return found
+}
+
+func (f *fakeExecutor) record(statement string) error {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.statements = append(f.statements, statement)
+
+	for i, failure := range f.failures {
+
+		if !strings.Contains(statement, failure.contains) {
+			continue
+		}
+
+		if failure.times == 1 {
+			f.failures = append(f.failures[:i:i], f.failures[i+1:]...)
+		} else if failure.times > 1 {
+			f.failures[i].times--
+		}
+
+		return failure.err
+	}
+
+	return nil
+}
+
+func (f *fakeExecutor) rows(query string) *fakeRows {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, q := range f.queries {
+		if strings.Contains(query, q.contains) {
+			return &fakeRows{columns: q.columns, rows: q.rows}
+		}
+	}
+
+	return &fakeRows{}
+}
+
+type fakeConnector struct {
+	f *fakeExecutor
+}
+
+func (c fakeConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	return &fakeConn{c.f}, nil
+}
+
+func (c fakeConnector) Driver() driver.Driver {
+	return fakeDriver{}
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+	return nil, errors.New("fake driver connections are opened by fakeExecutor")
+}
+
+type fakeConn struct {
+	f *fakeExecutor
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("fake driver does not prepare statements")
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+
+	if err := c.f.record("BEGIN"); err != nil {
+		return nil, err
+	}
+
+	return fakeTx{c.f}, nil
+}
+
+func (c *fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+
+	if err := c.f.record(query); err != nil {
+		return nil, err
+	}
+
+	if err := c.f.block(ctx, query); err != nil {
+		return nil, err
+	}
+
+	return driver.RowsAffected(c.f.rowsAffected(query)), nil
+}
+
+func (c *fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+
+	if err := c.f.record(query); err != nil {
+		return nil, err
+	}
+
+	return c.f.rows(query), nil
+}
+
+// CheckNamedValue accepts every argument as is
+func (c *fakeConn) CheckNamedValue(*driver.NamedValue) error {
+	return nil
+}
+
+type fakeTx struct {
+	f *fakeExecutor
+}
+
+func (t fakeTx) Commit() error {
+	return t.f.record("COMMIT")
+}
+
+func (t fakeTx) Rollback() error {
+	return t.f.record("ROLLBACK")
+}
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string {
+	return r.columns
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+
+	if len(r.rows) <= 0 {
+		return io.EOF
+	}
+
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+
+	return nil
+}
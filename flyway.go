@@ -0,0 +1,139 @@
+package goflyway
+
+import (
+	"database/sql"
+)
+
+// Flyway runs GoFlyway operations against one database. It is configured once by New and holds no state between
+// operations, so it is safe for concurrent use and several of them can serve different databases in the same process
+type Flyway struct {
+	config  GoFlywayConfig
+	dialect Dialect
+}
+
+// New validates the configuration, applies its defaults and returns a Flyway ready to run operations.
+// Later changes to the Schemas, Placeholders and LintRules of c do not affect the returned Flyway
+func New(c GoFlywayConfig) (*Flyway, error) {
+
+	if c.Schemas != nil {
+		c.Schemas = append([]string{}, c.Schemas...)
+	}
+
+	if c.Placeholders != nil {
+		placeholders := make(map[string]string, len(c.Placeholders))
+		for k, v := range c.Placeholders {
+			placeholders[k] = v
+		}
+		c.Placeholders = placeholders
+	}
+
+	if c.LintRules != nil {
+		rules := make(map[LintRule]LintSeverity, len(c.LintRules))
+		for k, v := range c.LintRules {
+			rules[k] = v
+		}
+		c.LintRules = rules
+	}
+
+	g, err := newGoFlywayRunner(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Flyway{
+		config:  g.config,
+		dialect: g.dialect,
+	}, nil
+}
+
+// Config returns the configuration of f with its defaults applied
+func (f *Flyway) Config() GoFlywayConfig {
+	return f.config
+}
+
+// runner Returns a runner for a single operation, the state of a run is not shared
+func (f *Flyway) runner() *goFlywayRunner {
+
+	return &goFlywayRunner{
+		config:      f.config,
+		dialect:     f.dialect,
+		initialized: true,
+	}
+}
+
+// Migrate apply migrations to database and returns a summary of the run, see Flyway.Migrate
+func Migrate(c GoFlywayConfig) (*MigrateResult, error) {
+
+	f, err := New(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return f.Migrate()
+}
+
+// MigrateTx applies migrations inside tx, a transaction of the caller, see Flyway.MigrateTx
+func MigrateTx(c GoFlywayConfig, tx *sql.Tx) (*MigrateResult, error) {
+
+	f, err := New(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return f.MigrateTx(tx)
+}
+
+// Info lists the applied and pending migrations, see Flyway.Info
+func Info(c GoFlywayConfig) ([]MigrationInfo, error) {
+
+	f, err := New(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return f.Info()
+}
+
+// Validate checks the local migrations against the schema history table, see Flyway.Validate
+func Validate(c GoFlywayConfig) error {
+
+	f, err := New(c)
+	if err != nil {
+		return err
+	}
+
+	return f.Validate()
+}
+
+// Baseline marks an existing database at BaselineVersion, see Flyway.Baseline
+func Baseline(c GoFlywayConfig) error {
+
+	f, err := New(c)
+	if err != nil {
+		return err
+	}
+
+	return f.Baseline()
+}
+
+// Repair fixes the schema history table, see Flyway.Repair
+func Repair(c GoFlywayConfig) (*RepairResult, error) {
+
+	f, err := New(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return f.Repair()
+}
+
+// Clean drops all objects of the configured schemas, see Flyway.Clean
+func Clean(c GoFlywayConfig) error {
+
+	f, err := New(c)
+	if err != nil {
+		return err
+	}
+
+	return f.Clean()
+}
@@ -0,0 +1,208 @@
+package goflyway
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Types of the schema history rows written by Java Flyway
+const (
+	flywayTypeSQL      = "SQL"
+	flywayTypeBaseline = "BASELINE"
+	flywayTypeDelete   = "DELETE"
+	flywayTypeSchema   = "SCHEMA"
+)
+
+const flywayTableName = "flyway_schema_history"
+
+// CalculateFlywayChecksum generate the file checksum the way Java Flyway does: a CRC32 of the UTF-8 lines of the
+// script, without line breaks and byte order mark
+func CalculateFlywayChecksum(filename string) (int32, error) {
+
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return 0, throwErrMigration(err)
+	}
+
+	return flywayChecksum(b), nil
+}
+
+func flywayChecksum(content []byte) int32 {
+
+	hasher := crc32.NewIEEE()
+
+	content = bytes.TrimPrefix(content, utf8BOM)
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 64*1024), len(content)+1)
+	scanner.Split(scanFlywayLines)
+
+	for scanner.Scan() {
+		hasher.Write(scanner.Bytes())
+	}
+
+	return int32(hasher.Sum32())
+}
+
+// scanFlywayLines Split lines on "\n", "\r" or "\r\n" like java.io.BufferedReader.readLine
+func scanFlywayLines(data []byte, atEOF bool) (int, []byte, error) {
+
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		if data[i] == '\r' {
+			if i+1 < len(data) {
+				if data[i+1] == '\n' {
+					return i + 2, data[:i], nil
+				}
+				return i + 1, data[:i], nil
+			}
+			if !atEOF {
+				// a "\n" may follow
+				return 0, nil, nil
+			}
+		}
+		return i + 1, data[:i], nil
+	}
+
+	if atEOF {
+		return len(data), data, nil
+	}
+
+	return 0, nil, nil
+}
+
+// compareVersions Compare versions part by part numerically, like Flyway. Leading zeros are ignored and missing parts are zero, so "1.0" equals "1".
+// It returns -1, 0 or 1
+func compareVersions(a string, b string) int {
+
+	pa := strings.Split(a, ".")
+	pb := strings.Split(b, ".")
+
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+
+		va, vb := "", ""
+		if i < len(pa) {
+			va = strings.TrimLeft(pa[i], "0")
+		}
+		if i < len(pb) {
+			vb = strings.TrimLeft(pb[i], "0")
+		}
+
+		if len(va) != len(vb) {
+			if len(va) < len(vb) {
+				return -1
+			}
+			return 1
+		}
+
+		if c := strings.Compare(va, vb); c != 0 {
+			return c
+		}
+	}
+
+	return 0
+}
+
+// resolveAppliedMigrations Reduce the schema history rows to the applied versioned migrations: Flyway schema creation
+// markers and repeatable migrations are ignored and migrations marked as deleted are removed. Baseline rows are kept
+// and the rows are ordered by version numerically
+func (g *goFlywayRunner) resolveAppliedMigrations(rows []historyModel) ([]historyModel, error) {
+
+	deleted := map[string]bool{}
+	for _, m := range rows {
+		if strings.EqualFold(m.Type, flywayTypeDelete) {
+			deleted[m.Version] = true
+		}
+	}
+
+	applied := []historyModel{}
+	repeatable := 0
+
+	for _, m := range rows {
+
+		if strings.EqualFold(m.Type, flywayTypeSchema) || strings.EqualFold(m.Type, flywayTypeDelete) || deleted[m.Version] {
+			continue
+		}
+
+		if len(m.Version) <= 0 {
+			repeatable++
+			continue
+		}
+
+		if !m.Success {
+			return nil, fmt.Errorf("detected failed migration to version %s (%s), remove any half-completed changes and the failed history row before migrating again",
+				m.Version, m.Description)
+		}
+
+		applied = append(applied, m)
+	}
+
+	if repeatable > 0 {
+		g.warn(fmt.Sprintf("warning: ignoring %d repeatable migrations of the schema history, they are not supported", repeatable))
+	}
+
+	sort.SliceStable(applied, func(i, j int) bool {
+		return compareVersions(applied[i].Version, applied[j].Version) < 0
+	})
+
+	return applied, nil
+}
+
+// baselineVersion Returns the version of the baseline row of the schema history, empty when there is none
+func baselineVersion(databaseMigrations []historyModel) string {
+
+	for _, m := range databaseMigrations {
+		if strings.EqualFold(m.Type, flywayTypeBaseline) {
+			return m.Version
+		}
+	}
+
+	return ""
+}
+
+// filterBaselined Drop the local migrations at or below the baseline version, they are considered applied
+func filterBaselined(localMigrations []localScript, databaseMigrations []historyModel) []localScript {
+
+	baseline := baselineVersion(databaseMigrations)
+	if len(baseline) <= 0 {
+		return localMigrations
+	}
+
+	filtered := []localScript{}
+	for _, lm := range localMigrations {
+		if compareVersions(lm.Version, baseline) > 0 {
+			filtered = append(filtered, lm)
+		}
+	}
+
+	return filtered
+}
+
+// checksumParam Returns the checksum bound in the history insert, an integer or NULL on Flyway history tables
+func (g *goFlywayRunner) checksumParam(checksum string) (interface{}, error) {
+
+	if !g.config.FlywayCompatible {
+		return checksum, nil
+	}
+
+	// baseline rows have no checksum
+	if len(checksum) <= 0 {
+		return nil, nil
+	}
+
+	n, err := strconv.ParseInt(checksum, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Flyway checksum %s: %w", checksum, err)
+	}
+
+	return int32(n), nil
+}
@@ -0,0 +1,91 @@
+package goflyway
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+)
+
+func TestFlywayChecksum(t *testing.T) {
+
+	lf := flywayChecksum([]byte("CREATE TABLE a (id INT);\nINSERT INTO a VALUES (1);\n"))
+
+	if crlf := flywayChecksum([]byte("CREATE TABLE a (id INT);\r\nINSERT INTO a VALUES (1);\r\n")); crlf != lf {
+		t.Errorf("expected CRLF checksum %d equal to LF checksum %d", crlf, lf)
+	}
+
+	if bom := flywayChecksum([]byte("\xef\xbb\xbfCREATE TABLE a (id INT);\nINSERT INTO a VALUES (1);\n")); bom != lf {
+		t.Errorf("expected BOM checksum %d equal to checksum %d", bom, lf)
+	}
+
+	if other := flywayChecksum([]byte("CREATE TABLE b (id INT);\n")); other == lf {
+		t.Errorf("expected different checksums for different scripts")
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"1", "2", -1},
+		{"1.10", "1.9", 1},
+		{"1.0", "1", 0},
+		{"2", "10", -1},
+		{"1.01", "1.1", 0},
+	}
+
+	for _, tt := range tests {
+		if got := compareVersions(tt.a, tt.b); got != tt.expected {
+			t.Errorf("compareVersions(%s, %s) expected %d but got %d", tt.a, tt.b, tt.expected, got)
+		}
+	}
+}
+
+func TestResolveAppliedMigrations(t *testing.T) {
+
+	g := &goFlywayRunner{config: GoFlywayConfig{FlywayCompatible: true}}
+
+	rows := []historyModel{
+		{InstalledRank: 0, Type: flywayTypeSchema, Description: "<< Flyway Schema Creation >>", Success: true},
+		{InstalledRank: 1, Version: "1.10", Type: flywayTypeSQL, Success: true},
+		{InstalledRank: 2, Version: "1.9", Type: flywayTypeSQL, Success: true},
+		{InstalledRank: 3, Type: flywayTypeSQL, Description: "repeatable", Success: true},
+		{InstalledRank: 4, Version: "2", Type: flywayTypeSQL, Success: true},
+		{InstalledRank: 5, Version: "2", Type: flywayTypeDelete, Success: true},
+	}
+
+	applied, err := g.resolveAppliedMigrations(rows)
+	if err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	if len(applied) != 2 || applied[0].Version != "1.9" || applied[1].Version != "1.10" {
+		t.Errorf("expected versions 1.9 and 1.10 but got %v", applied)
+	}
+
+	if len(g.warnings) != 1 {
+		t.Errorf("expected a warning for the repeatable migration but got %v", g.warnings)
+	}
+
+	_, err = g.resolveAppliedMigrations([]historyModel{{Version: "1", Type: flywayTypeSQL, Success: false}})
+	if err == nil {
+		t.Errorf("expected error for failed migration but got nil")
+	}
+}
+
+func TestFlywayCompatibleNotSupported(t *testing.T) {
+
+	RegisterDialect("noflyway", &noFlywayDialect{})
+
+	_, err := Migrate(GoFlywayConfig{Db: &sql.DB{}, Driver: "noflyway", FlywayCompatible: true, Location: "."})
+	if !errors.Is(err, ErrFlywayCompatibilityNotSupported) {
+		t.Errorf("expected ErrFlywayCompatibilityNotSupported but got %v", err)
+	}
+}
+
+// noFlywayDialect A dialect without FlywayDialect support
+type noFlywayDialect struct {
+	Dialect
+}
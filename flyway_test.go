@@ -0,0 +1,29 @@
+package goflyway
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNewFlyway(t *testing.T) {
+
+	if _, err := New(GoFlywayConfig{Driver: SQLITE3}); !errors.Is(err, ErrLocationCannotBeEmpty) {
+		t.Errorf("expected ErrLocationCannotBeEmpty but got %v", err)
+	}
+
+	if _, err := New(GoFlywayConfig{Driver: "oracle", Location: "."}); !errors.Is(err, ErrUnsupportedDatabaseDriver) {
+		t.Errorf("expected ErrUnsupportedDatabaseDriver but got %v", err)
+	}
+
+	schemas := []string{"main"}
+	f, err := New(GoFlywayConfig{Driver: SQLITE3, Location: ".", Schemas: schemas})
+	if err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	schemas[0] = "changed"
+
+	if c := f.Config(); c.Table != tableName || c.Schemas[0] != "main" || c.DefaultSchema != "main" {
+		t.Errorf("expected configuration with defaults unaffected by later changes but got %+v", c)
+	}
+}
@@ -1,12 +1,17 @@
 package goflyway
 
 import (
+	"context"
 	"crypto/sha256"
 	"database/sql"
+	"database/sql/driver"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
+	"log"
 	"os"
+	"regexp"
 	"sort"
 	"strings"
 	"time"
@@ -42,6 +47,15 @@ type GoFlywayConfig struct {
 	// File name separator for SQL migrations. Default is "__"
 	SqlMigrationSeparator string
 
+	// Schemas managed by GoFlyway. On POSTGRES they are set as search_path while migration scripts run
+	Schemas []string
+
+	// Schema that holds the schema history table. Default is the first of Schemas, or the connection default schema
+	DefaultSchema string
+
+	// Whether to create DefaultSchema and Schemas when they do not exist. Default is "false"
+	CreateSchemas bool
+
 	// Location of migrations scripts. Examle: "/home/user/my-project/migrations"
 	Location string
 
@@ -51,57 +65,532 @@ type GoFlywayConfig struct {
 	// Ignore missing migrations. Default is "false"
 	IgnoreMissingMigrations bool
 
-	// Database connection
-	Db *sql.DB
+	// Database connection, a *sql.DB, a *sql.Conn or any other Executor. Migrate, Validate, Baseline, Repair and Clean
+	// run on a single database session, a connection taken from *sql.DB with Conn or the *sql.Conn itself
+	Db Executor
+
+	// Database connection string, opened and closed by each operation when Db is not set
+	DSN string
+
+	// database/sql driver used to open DSN, it must be imported by the caller. Default is "pgx" for POSTGRES and
+	// COCKROACHDB, "mysql" for MYSQL and MARIADB, "sqlserver" for MSSQLSERVER and "sqlite" for SQLITE3
+	SqlDriver string
+
+	// Whether Migrate and Baseline create the database of DSN when it does not exist, connecting to the maintenance
+	// database of the server. Not supported on SQLITE3, whose database files are created on connection. Default is "false"
+	CreateDatabase bool
+
+	// Encoding of the database created by CreateDatabase, such as UTF8 or utf8mb4. Default is the server default
+	DatabaseEncoding string
+
+	// Collation of the database created by CreateDatabase. Default is the server default
+	DatabaseCollation string
+
+	// Number of retries when connecting to DSN fails, for databases that start with the application. Default is 0
+	ConnectRetries int
+
+	// Longest wait between connection attempts, the wait starts at one second and doubles after each attempt.
+	// Default is 120 seconds
+	ConnectRetriesInterval time.Duration
+
+	// SQL executed once on the connection of the run before the migration lock is taken, for session settings such as
+	// SET search_path, SET ROLE or SET sql_mode that the migration scripts rely on
+	InitSQL string
+
+	// Longest time a migration script may run, a script overrides it with a "-- goflyway:timeout=30s" line. The
+	// script is cancelled and rolled back when it is exceeded. Default is no timeout
+	ScriptTimeout time.Duration
+
+	// Longest wait of each statement for a lock, set on the session after the migration lock is taken, so a script
+	// blocked behind a long transaction fails instead of queueing writes behind it. Default is the server default
+	LockTimeout time.Duration
+
+	// Longest time of each statement, set on the session after the migration lock is taken. Not supported on
+	// MSSQLSERVER and SQLITE3, use ScriptTimeout. Default is the server default
+	StatementTimeout time.Duration
+
+	// Age after which the migration lock of COCKROACHDB, a row left behind by a run that crashed, is taken over. A
+	// migration must not run longer. Default is 1 hour, a negative value never takes the lock over
+	StaleLockAge time.Duration
 
-	// Database drive
-	Driver driver
+	// Number of retries of a migration whose transaction fails with a transient error of the database, such as a
+	// deadlock, a lock timeout or a serialization conflict. Migrations are not retried inside MigrateTx, nor on
+	// MYSQL and MARIADB once a statement of the script succeeded. Default is 0, migrations are not retried except the
+	// serialization conflicts of COCKROACHDB, retried 5 times. A negative value disables all retries
+	ScriptRetries int
+
+	// Longest wait between the attempts of a migration, the wait starts at 100 milliseconds and doubles after each
+	// attempt. Default is 10 seconds
+	ScriptRetriesInterval time.Duration
+
+	// Database drive, one of the built-in drivers or a name registered with RegisterDialect
+	Driver Driver
+
+	// Value stored in the installed_by column of the schema history table. Default is the current database user
+	InstalledBy string
+
+	// Derives installed_by when InstalledBy is empty, for example InstalledByFromCI.
+	// When it returns an empty string the current database user is used
+	InstalledByResolver func() string
+
+	// Reads and writes the schema history table of Java Flyway, with its column layout, CRC32 checksums and
+	// version ordering, so GoFlyway can take over databases migrated by Flyway. Default Table becomes "flyway_schema_history"
+	FlywayCompatible bool
+
+	// Algorithm of the script checksums stored in the schema history table. Default is "sha256", "crc32" when FlywayCompatible
+	ChecksumAlgorithm ChecksumAlgorithm
+
+	// Whether to compute checksums without UTF-8 byte order mark and with LF line endings, so checkouts with CRLF line
+	// endings match. Run Repair after enabling it on a schema with applied migrations. Default is "false"
+	NormalizeChecksums bool
+
+	// Whether to ignore the trailing whitespace of each line in checksums. Run Repair after enabling it on a schema with
+	// applied migrations. Default is "false"
+	ChecksumTrimTrailingWhitespace bool
 
 	// Shows warning logs. Default is "false"
 	ShowWarningLog bool
 
+	// Receives the progress and warning logs. Default is the standard logger of package log
+	Logger Logger
+
+	// Whether Clean may drop the objects of the configured schemas, Clean returns ErrCleanDisabled otherwise.
+	// Default is "false"
+	CleanEnabled bool
+
+	// Version recorded by Baseline. Default is "1"
+	BaselineVersion string
+
+	// Description recorded by Baseline. Default is "<< GoFlyway Baseline >>"
+	BaselineDescription string
+
+	// How NewMigration picks the version of the next migration, VersionIncrement or VersionTimestamp. Default is "increment"
+	VersionStrategy VersionStrategy
+
+	// Values replacing ${name} placeholders in migration scripts before they run. Checksums are computed
+	// on the scripts as written. Placeholders without value are kept as is
+	Placeholders map[string]string
+
+	// Severity of the rules checked by Lint, overriding their defaults. LintOff disables a rule
+	LintRules map[LintRule]LintSeverity
+
 	// File name sufix for SQL migrations. Default is ".sql"
 	sqlMigrationSuffix string
 }
 
+// Logger receives the logs of GoFlyway, *log.Logger implements it
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// MigrateResult summarizes a Migrate run
+type MigrateResult struct {
+	// Schema version before the run. Empty when no migration had been applied yet
+	InitialSchemaVersion string
+
+	// Schema version after the run
+	TargetSchemaVersion string
+
+	// Migrations applied during the run, in execution order
+	Migrations []MigrateOutput
+
+	// Total of migrations applied during the run
+	MigrationsExecuted int
+
+	// Warnings raised while resolving and applying migrations
+	Warnings []string
+
+	// Total duration of the run
+	TotalMigrationTime time.Duration
+}
+
+// MigrateOutput describes a single migration applied by Migrate
+type MigrateOutput struct {
+	Version       string
+	Description   string
+	Type          string
+	Script        string
+	ExecutionTime time.Duration
+	RowsAffected  int64
+}
+
+// RepairResult summary of a Repair run
+type RepairResult struct {
+	// Failed migrations removed from the schema history table
+	MigrationsRemoved []RepairOutput
+
+	// Applied migrations whose checksum and description were realigned with the local scripts
+	MigrationsAligned []RepairOutput
+
+	Warnings []string
+}
+
+// RepairOutput a schema history row changed by Repair
+type RepairOutput struct {
+	Version     string
+	Description string
+	Script      string
+}
+
 type goFlywayRunner struct {
 	config      GoFlywayConfig
+	dialect     Dialect
+	installedBy string
 	initialized bool
+	warnings    []string
+
+	// largest installed_rank of the schema history, including the rows ignored as not applied migrations
+	lastInstalledRank int
+
+	// transaction of the caller of MigrateTx, scripts and history rows run in it instead of their own transactions
+	tx *sql.Tx
 }
 
-// Migrate apply migrations to database and returns the total of executed migrations
-func Migrate(c GoFlywayConfig) (int, error) {
+// Migrate apply migrations to database and returns a summary of the run.
+// When a migration fails, the returned result holds the migrations applied before the failure
+func (f *Flyway) Migrate() (*MigrateResult, error) {
+
+	startExec := time.Now()
+
+	g := f.runner()
 
-	g, err := newGoFlywayRunner(c)
+	if !g.initialized {
+		return nil, ErrRunnerNotInitialized
+	}
+
+	err := g.createDatabase()
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
-	if !g.initialized {
-		return 0, ErrRunnerNotInitialized
+	unlock, err := g.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	return g.migrate(startExec)
+}
+
+// MigrateTx applies the pending migrations and their schema history rows inside tx, an open transaction of the
+// caller, who is responsible for committing or rolling it back. The migration lock is not taken, concurrent runs
+// must be serialized by the caller. Scripts holding a statement that cannot run in a transaction are refused with
+// ErrNonTransactionalMigration before any script runs, see NonTransactionalDialect. The search path in effect in tx
+// is restored once the scripts are applied. Failed scripts are not retried, tx must be rolled back
+func (f *Flyway) MigrateTx(tx *sql.Tx) (*MigrateResult, error) {
+
+	startExec := time.Now()
+
+	if tx == nil {
+		return nil, ErrDatabaseConnectionNull
+	}
+
+	g := f.runner()
+
+	g.tx = tx
+	g.config.Db = txExecutor{tx}
+
+	ctx := context.Background()
+
+	err := g.initSession(ctx)
+	if err != nil {
+		return nil, throwErrMigration(fmt.Errorf("error executing init SQL: %w", err))
+	}
+
+	err = g.checkHistoryTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	restore, err := g.saveSearchPath(ctx)
+	if err != nil {
+		return nil, throwErrMigration(fmt.Errorf("error reading search path: %w", err))
+	}
+
+	result, err := g.migrate(startExec)
+	if err != nil {
+		return result, err
+	}
+
+	if err = restore(); err != nil {
+		return result, throwErrMigration(fmt.Errorf("error restoring search path: %w", err))
+	}
+
+	return result, nil
+}
+
+// checkHistoryTx Refuse MigrateTx when the dialect commits DDL statements implicitly and the schemas or the schema
+// history table would have to be created or upgraded, which would commit the transaction of the caller
+func (g *goFlywayRunner) checkHistoryTx(ctx context.Context) error {
+
+	if supportsTransactionalDDL(g.dialect) {
+		return nil
+	}
+
+	refuse := func(reason string) error {
+		return fmt.Errorf("%w: %s commits DDL statements implicitly, %s", ErrNonTransactionalMigration, g.config.Driver, reason)
+	}
+
+	if g.config.CreateSchemas {
+		return refuse("CreateSchemas is not supported")
+	}
+
+	exists, err := g.historyTableExists(ctx)
+	if err != nil {
+		return throwErrMigration(fmt.Errorf("error reading migration table: %w", err))
+	}
+
+	if !exists {
+		return refuse("run Migrate or Baseline first to create the schema history table")
+	}
+
+	if _, ok := g.dialect.(HistoryUpgradeDialect); ok && !g.config.FlywayCompatible {
+
+		query := fillTemplate(selectLayoutVersion, g.dialect, g.config.DefaultSchema, layoutTableName(g.config.Table))
+
+		var version int
+		if err = queryRow(ctx, g.config.Db, query, nil, &version); err != nil || version < historyLayoutVersion {
+			return refuse("run Migrate first to upgrade the schema history table")
+		}
+	}
+
+	return nil
+}
+
+// saveSearchPath Read the search path in effect in the transaction of MigrateTx, the returned function sets it back
+// after the scripts changed it. Nothing is saved when the dialect is not a SearchPathRestorer
+func (g *goFlywayRunner) saveSearchPath(ctx context.Context) (func() error, error) {
+
+	d, ok := g.dialect.(SearchPathRestorer)
+	if !ok || len(g.dialect.SearchPath(g.schemas())) <= 0 {
+		return func() error { return nil }, nil
+	}
+
+	var searchPath string
+	if err := queryRow(ctx, g.config.Db, d.CurrentSearchPath(), nil, &searchPath); err != nil {
+		return nil, err
 	}
 
+	return func() error {
+		_, err := g.config.Db.ExecContext(ctx, d.RestoreSearchPath(), searchPath)
+		return err
+	}, nil
+}
+
+// checkTransactional Refuse the pending scripts holding a statement that cannot run in the transaction of MigrateTx
+func (g *goFlywayRunner) checkTransactional(localMigrations []localScript, databaseMigrations []historyModel) error {
+
+	for _, lm := range localMigrations {
+
+		if findMigrationByVersion(databaseMigrations, lm.Version) != nil {
+			continue
+		}
+
+		b, err := os.ReadFile(fmt.Sprintf("%s/%s", g.config.Location, lm.Script))
+		if err != nil {
+			return throwErrMigration(fmt.Errorf("error reading migration %s: %w", lm.Script, err))
+		}
+
+		for _, st := range g.lintStatements(replacePlaceholders(string(b), g.config.Placeholders)) {
+			if isNonTransactional(g.dialect, st.code) {
+				return fmt.Errorf("%w: %s line %d cannot run in a transaction on %s: %s",
+					ErrNonTransactionalMigration, lm.Script, st.line, g.config.Driver, st.first)
+			}
+		}
+	}
+
+	return nil
+}
+
+// migrate Validate the local migrations against the schema history table and apply the pending ones
+func (g *goFlywayRunner) migrate(startExec time.Time) (*MigrateResult, error) {
+
 	mFiles, err := g.readLocalMigrations()
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
 	mTable, err := g.readMigrationTable()
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
+	mFiles = filterBaselined(mFiles, mTable)
+
 	err = g.validateMigrations(mFiles, mTable)
 	if err != nil {
-		return 0, err
+		return nil, err
+	}
+
+	if g.tx != nil {
+		err = g.checkTransactional(mFiles, mTable)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = g.resolveInstalledBy()
+	if err != nil {
+		return nil, err
 	}
 
-	total, err := g.applyMigrations(mFiles, mTable)
+	result, err := g.applyMigrations(mFiles, mTable)
+	result.Warnings = g.warnings
+	result.TotalMigrationTime = time.Since(startExec)
+
+	return result, err
+}
+
+// Clean drops all objects of the configured schemas, including the schema history table.
+// When no schema is configured the connection default schema is cleaned
+func (f *Flyway) Clean() error {
+
+	g := f.runner()
+
+	if !g.config.CleanEnabled {
+		return ErrCleanDisabled
+	}
+
+	disconnect, err := g.connect()
 	if err != nil {
-		return 0, err
+		return err
 	}
+	defer disconnect()
+
+	ctx := context.Background()
+
+	conn, release, err := sessionConn(ctx, g.config.Db)
+	if err != nil {
+		return throwErrMigration(fmt.Errorf("error cleaning database: %w", err))
+	}
+	defer release()
+
+	g.config.Db = conn
 
-	return total, nil
+	err = g.initSession(ctx)
+	if err != nil {
+		return throwErrMigration(fmt.Errorf("error executing init SQL: %w", err))
+	}
+
+	err = g.dialect.Clean(ctx, conn, g.schemas())
+	if err != nil {
+		return throwErrMigration(fmt.Errorf("error cleaning database: %w", err))
+	}
+
+	g.config.Logger.Printf("successfully cleaned schemas %v", g.schemas())
+
+	return nil
+}
+
+// Validate checks the local migrations against the schema history table without applying them: checksums,
+// descriptions, duplicated versions, order and missing migrations
+func (f *Flyway) Validate() error {
+
+	g := f.runner()
+
+	unlock, err := g.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	mFiles, err := g.readLocalMigrations()
+	if err != nil {
+		return err
+	}
+
+	mTable, err := g.readMigrationTable()
+	if err != nil {
+		return err
+	}
+
+	return g.validateMigrations(filterBaselined(mFiles, mTable), mTable)
+}
+
+// Baseline marks an existing database at BaselineVersion, so Migrate only applies the migrations above it.
+// The schema history table must not have applied migrations
+func (f *Flyway) Baseline() error {
+
+	g := f.runner()
+
+	err := g.createDatabase()
+	if err != nil {
+		return err
+	}
+
+	unlock, err := g.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	mTable, err := g.readMigrationTable()
+	if err != nil {
+		return err
+	}
+
+	if len(mTable) > 0 {
+		return fmt.Errorf("%w: %s has %d applied migrations", ErrBaselineNotEmpty, g.config.Table, len(mTable))
+	}
+
+	err = g.resolveInstalledBy()
+	if err != nil {
+		return err
+	}
+
+	baseline := historyModel{
+		InstalledRank: g.lastInstalledRank + 1,
+		Version:       g.config.BaselineVersion,
+		Description:   g.config.BaselineDescription,
+		Type:          flywayTypeBaseline,
+		Script:        g.config.BaselineDescription,
+		InstalledBy:   g.installedBy,
+	}
+
+	_, err = insertMigration(context.Background(), g.config.Db, g.dialect.InsertHistory(g.config.DefaultSchema, g.config.Table), baseline, g)
+	if err != nil {
+		return throwErrMigration(err)
+	}
+
+	g.config.Logger.Printf("successfully baselined schema with version %s", g.config.BaselineVersion)
+
+	return nil
+}
+
+// Repair fixes the schema history table: failed migrations are removed and the checksums and descriptions of applied
+// migrations are realigned with the local scripts, for example after changing ChecksumAlgorithm or NormalizeChecksums
+func (f *Flyway) Repair() (*RepairResult, error) {
+
+	g := f.runner()
+
+	d, ok := g.dialect.(RepairDialect)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrRepairNotSupported, g.config.Driver)
+	}
+
+	unlock, err := g.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	mFiles, err := g.readLocalMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	mTable, err := g.readHistory()
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := g.repairHistory(d, mFiles, mTable)
+	if err != nil {
+		return nil, throwErrMigration(fmt.Errorf("error repairing migration table: %w", err))
+	}
+	result.Warnings = g.warnings
+
+	return result, nil
 }
 
 // CalculateChecksum generate file checksum, it is used to check script integrity
@@ -122,6 +611,19 @@ func CalculateChecksum(filename string) (string, error) {
 	return hex.EncodeToString(hasher.Sum(nil)), nil
 }
 
+// InstalledByFromCI returns the user that triggered the current CI pipeline, read from the environment variables of
+// common CI systems. GOFLYWAY_INSTALLED_BY takes precedence. It returns an empty string outside CI
+func InstalledByFromCI() string {
+
+	for _, env := range ciInstalledByVariables {
+		if v := strings.TrimSpace(os.Getenv(env)); len(v) > 0 {
+			return v
+		}
+	}
+
+	return ""
+}
+
 func newGoFlywayRunner(config GoFlywayConfig) (*goFlywayRunner, error) {
 
 	c := &goFlywayRunner{
@@ -133,6 +635,11 @@ func newGoFlywayRunner(config GoFlywayConfig) (*goFlywayRunner, error) {
 		return nil, err
 	}
 
+	err = c.resolveDialect()
+	if err != nil {
+		return nil, err
+	}
+
 	c.initialized = true
 
 	return c, nil
@@ -143,10 +650,38 @@ func (g *goFlywayRunner) applyDefaultSettings() error {
 
 	g.config.sqlMigrationSuffix = ".sql"
 
+	if len(g.config.Table) <= 0 && g.config.FlywayCompatible {
+		g.config.Table = flywayTableName
+	}
+
 	if len(g.config.Table) <= 0 {
 		g.config.Table = tableName
 	}
 
+	if len(g.config.ChecksumAlgorithm) <= 0 && g.config.FlywayCompatible {
+		g.config.ChecksumAlgorithm = ChecksumCRC32
+	}
+
+	if len(g.config.ChecksumAlgorithm) <= 0 {
+		g.config.ChecksumAlgorithm = ChecksumSHA256
+	}
+
+	switch {
+	case g.config.ChecksumAlgorithm != ChecksumSHA256 && g.config.ChecksumAlgorithm != ChecksumCRC32:
+		return fmt.Errorf("%w: %s", ErrUnsupportedChecksumAlgorithm, g.config.ChecksumAlgorithm)
+	case g.config.FlywayCompatible && g.config.ChecksumAlgorithm != ChecksumCRC32:
+		return fmt.Errorf("%w: %s, Flyway compatible history tables store %s checksums",
+			ErrUnsupportedChecksumAlgorithm, g.config.ChecksumAlgorithm, ChecksumCRC32)
+	}
+
+	if len(g.config.BaselineVersion) <= 0 {
+		g.config.BaselineVersion = baselineVersionDefault
+	}
+
+	if len(g.config.BaselineDescription) <= 0 {
+		g.config.BaselineDescription = baselineDescription
+	}
+
 	if len(g.config.SqlMigrationPrefix) <= 0 {
 		g.config.SqlMigrationPrefix = sqlMigrationPrefix
 	}
@@ -159,12 +694,33 @@ func (g *goFlywayRunner) applyDefaultSettings() error {
 		return ErrLocationCannotBeEmpty
 	}
 
-	err := validateDriver(string(g.config.Driver))
+	if len(g.config.DefaultSchema) <= 0 && len(g.config.Schemas) > 0 {
+		g.config.DefaultSchema = g.config.Schemas[0]
+	}
+
+	if len(g.config.VersionStrategy) <= 0 {
+		g.config.VersionStrategy = VersionIncrement
+	}
+
+	if g.config.Logger == nil {
+		g.config.Logger = log.Default()
+	}
+
+	return nil
+}
+
+// resolveDialect Look up the dialect of the configured driver
+func (g *goFlywayRunner) resolveDialect() error {
+
+	d, err := getDialect(g.config.Driver)
 	if err != nil {
 		return err
 	}
+	g.dialect = d
 
-	showWarningLog = g.config.ShowWarningLog
+	if _, ok := d.(FlywayDialect); g.config.FlywayCompatible && !ok {
+		return fmt.Errorf("%w: %s", ErrFlywayCompatibilityNotSupported, g.config.Driver)
+	}
 
 	return nil
 }
@@ -173,7 +729,7 @@ func (g *goFlywayRunner) applyDefaultSettings() error {
 func (g *goFlywayRunner) readLocalMigrations() ([]localScript, error) {
 
 	fail := func(err error) ([]localScript, error) {
-		return nil, throwErrMigration(fmt.Errorf("error reading local migrations: %v", err))
+		return nil, throwErrMigration(fmt.Errorf("error reading local migrations: %w", err))
 	}
 
 	c := g.config
@@ -185,17 +741,17 @@ func (g *goFlywayRunner) readLocalMigrations() ([]localScript, error) {
 	}
 
 	if len(migrationDir) <= 0 {
-		printWarningLog(warnNoMigrationFound)
+		g.warn(warnNoMigrationFound)
 	}
 
 	for _, f := range migrationDir {
 
 		if strings.HasPrefix(f.Name(), c.SqlMigrationPrefix) && strings.HasSuffix(f.Name(), c.sqlMigrationSuffix) {
 			version, description, err := extractValuesFromScriptName(
-				f.Name(), g.config.SqlMigrationPrefix, g.config.SqlMigrationSeparator, g.config.sqlMigrationSuffix)
+				f.Name(), g.config.SqlMigrationPrefix, g.config.SqlMigrationSeparator, g.config.sqlMigrationSuffix, g.versionPattern())
 
 			if err != nil {
-				printWarningLog(fmt.Sprintf("warning: %v", err))
+				g.warn(fmt.Sprintf("warning: %v", err))
 			} else {
 				sf := localScript{
 					Version:     version,
@@ -203,9 +759,9 @@ func (g *goFlywayRunner) readLocalMigrations() ([]localScript, error) {
 					Script:      f.Name(),
 				}
 
-				cSfileCheckSumum, err := CalculateChecksum(fmt.Sprintf("%s/%s", c.Location, sf.Script))
+				cSfileCheckSumum, err := g.calculateChecksum(fmt.Sprintf("%s/%s", c.Location, sf.Script))
 				if err != nil {
-					printWarningLog(fmt.Sprintf("warning: checksum calculation error for script %s: %v ", sf.Script, err))
+					g.warn(fmt.Sprintf("warning: checksum calculation error for script %s: %v ", sf.Script, err))
 				} else {
 					sf.Checksum = cSfileCheckSumum
 					sqlFiles = append(sqlFiles, sf)
@@ -215,11 +771,11 @@ func (g *goFlywayRunner) readLocalMigrations() ([]localScript, error) {
 	}
 
 	if len(sqlFiles) <= 0 {
-		printWarningLog(warnNoMigrationFound)
+		g.warn(warnNoMigrationFound)
 	}
 
 	sort.SliceStable(sqlFiles, func(i, j int) bool {
-		return sqlFiles[i].Version < sqlFiles[j].Version
+		return compareVersions(sqlFiles[i].Version, sqlFiles[j].Version) < 0
 	})
 
 	return sqlFiles, nil
@@ -228,8 +784,24 @@ func (g *goFlywayRunner) readLocalMigrations() ([]localScript, error) {
 // ReadMigrationTable Load database migrations
 func (g *goFlywayRunner) readMigrationTable() ([]historyModel, error) {
 
+	migrations, err := g.readHistory()
+	if err != nil {
+		return nil, err
+	}
+
+	migrations, err = g.resolveAppliedMigrations(migrations)
+	if err != nil {
+		return nil, throwErrMigration(fmt.Errorf("error reading migration table: %w", err))
+	}
+
+	return migrations, nil
+}
+
+// readHistory Create the schema history table when missing and load all of its rows
+func (g *goFlywayRunner) readHistory() ([]historyModel, error) {
+
 	fail := func(err error) ([]historyModel, error) {
-		return nil, throwErrMigration(fmt.Errorf("error reading migration table: %v", err))
+		return nil, throwErrMigration(fmt.Errorf("error reading migration table: %w", err))
 	}
 
 	db := g.config.Db
@@ -237,23 +809,64 @@ func (g *goFlywayRunner) readMigrationTable() ([]historyModel, error) {
 		return fail(ErrDatabaseConnectionNull)
 	}
 
-	tableValue := g.config.Table
+	// checkHistoryTx made sure no DDL statement is needed when it would commit the transaction of MigrateTx
+	setup := g.tx == nil || supportsTransactionalDDL(g.dialect)
+
+	if g.config.CreateSchemas && setup {
+		for _, schema := range g.schemas() {
+
+			queryCreateSchema, err := g.dialect.CreateSchema(schema)
+			if err != nil {
+				return fail(err)
+			}
+
+			_, err = db.ExecContext(context.Background(), queryCreateSchema)
+			if err != nil {
+				return fail(err)
+			}
+		}
+	}
+
+	if d, ok := g.dialect.(HistoryUpgradeDialect); ok && !g.config.FlywayCompatible && setup {
+		if err := g.upgradeHistoryLayout(d); err != nil {
+			return fail(err)
+		}
+	}
+
+	exists := !setup
+	if checker, ok := g.dialect.(HistoryTableChecker); ok && setup {
+
+		var err error
+		exists, err = checker.HistoryTableExists(context.Background(), db, g.config.DefaultSchema, g.config.Table)
+		if err != nil {
+			return fail(err)
+		}
+	}
 
 	// always try to create history table to evict errors
-	queryCreateTable := getCreateTableCommand(g.config.Driver, g.config.Table)
+	if !exists {
+		queryCreateTable := g.dialect.CreateHistoryTable(g.config.DefaultSchema, g.config.Table)
+		if g.config.FlywayCompatible {
+			queryCreateTable = g.dialect.(FlywayDialect).CreateFlywayHistoryTable(g.config.DefaultSchema, g.config.Table)
+		}
 
-	_, err := db.Exec(queryCreateTable)
-	if err != nil {
-		return fail(err)
+		for _, statement := range g.dialect.SplitStatements(queryCreateTable) {
+			_, err := db.ExecContext(context.Background(), statement)
+			if err != nil {
+				return fail(err)
+			}
+		}
 	}
 
 	// list  migrations
-	queryTable := getSelectTableCommand(g.config.Driver, tableValue)
-	migrations, err := selectMigrationHistory(g.config.Db, queryTable)
+	queryTable := g.dialect.SelectHistory(g.config.DefaultSchema, g.config.Table)
+	migrations, err := selectMigrationHistory(context.Background(), g.config.Db, queryTable, g)
 	if err != nil {
 		return fail(err)
 	}
 
+	g.lastInstalledRank = findLargestInstalledRank(migrations)
+
 	return migrations, nil
 }
 
@@ -261,15 +874,20 @@ func (g *goFlywayRunner) validateMigrations(localMigrations []localScript, datab
 
 	startExec := time.Now().UnixMilli()
 
+	// local migrations below the latest applied version that were not applied are out of order
+	latestVersion := findLatestVersion(databaseMigrations)
+
 	// validate local migrations
-	for i, lm := range localMigrations {
+	for _, lm := range localMigrations {
 
 		// check if local migrations has duplicated version
 		dupLocalMg := findLocalMigrationsByVersion(localMigrations, lm.Version)
 
 		if len(dupLocalMg) > 1 {
-			return throwErrMigration(fmt.Errorf("found more than one migration with version %s: %s",
-				lm.Version, getScriptNames(dupLocalMg)))
+			return throwErrMigration(&DuplicateVersionError{
+				Version: lm.Version,
+				Scripts: getScriptNames(dupLocalMg),
+			})
 		}
 
 		dm := findMigrationByVersion(databaseMigrations, lm.Version)
@@ -277,23 +895,32 @@ func (g *goFlywayRunner) validateMigrations(localMigrations []localScript, datab
 		if dm != nil {
 
 			if dm.Checksum != lm.Checksum {
-				return throwErrMigration(fmt.Errorf("migration checksum mismatch for migration version %s: applied to database = %s, resolved locally = %s",
-					lm.Version, dm.Checksum, lm.Checksum))
+				return throwErrMigration(&ChecksumMismatchError{
+					Version:  lm.Version,
+					Script:   lm.Script,
+					Expected: dm.Checksum,
+					Actual:   lm.Checksum,
+				})
 			}
 
 			if dm.Description != lm.Description {
-				return throwErrMigration(fmt.Errorf("migration description mismatch for migration version %s: applied to database = %s, resolved locally = %s",
-					lm.Version, dm.Description, lm.Description))
+				return throwErrMigration(&DescriptionMismatchError{
+					Version:  lm.Version,
+					Script:   lm.Script,
+					Expected: dm.Description,
+					Actual:   lm.Description,
+				})
 			}
 		}
 
 		// check if is out of order
 		if !g.config.OutOfOrder {
 
-			migrationIndex := findMigrationIndexByVersion(databaseMigrations, lm.Version)
-			if migrationIndex == -1 && i < len(databaseMigrations) {
-				return throwErrMigration(fmt.Errorf("detected resolved migration not applied to database: %s, to allow executing this migration, set OutOfOrder=true",
-					lm.Version))
+			if dm == nil && len(latestVersion) > 0 && compareVersions(lm.Version, latestVersion) < 0 {
+				return throwErrMigration(&OutOfOrderError{
+					Version: lm.Version,
+					Script:  lm.Script,
+				})
 			}
 		}
 	}
@@ -302,12 +929,15 @@ func (g *goFlywayRunner) validateMigrations(localMigrations []localScript, datab
 	for _, dm := range databaseMigrations {
 
 		// check if any applied migration is missing
-		if !g.config.IgnoreMissingMigrations {
+		if !g.config.IgnoreMissingMigrations && !strings.EqualFold(dm.Type, flywayTypeBaseline) {
 
 			lm := findLocalMigrationByVersion(localMigrations, dm.Version)
 
 			if lm == nil {
-				return throwErrMigration(fmt.Errorf("detected applied migration not resolved locally: %s", dm.Version))
+				return throwErrMigration(&MissingMigrationError{
+					Version: dm.Version,
+					Script:  dm.Script,
+				})
 			}
 		}
 	}
@@ -316,30 +946,38 @@ func (g *goFlywayRunner) validateMigrations(localMigrations []localScript, datab
 
 	executionTime := int(endExec - startExec)
 
-	logg.Printf("successfully validated %d migrations (execution time %dms)",
+	g.config.Logger.Printf("successfully validated %d migrations (execution time %dms)",
 		len(localMigrations), executionTime) // TODO format to time
 
 	return nil
 }
 
-func (gr *goFlywayRunner) applyMigrations(localMigrations []localScript, databaseMigrations []historyModel) (int, error) {
+func (gr *goFlywayRunner) applyMigrations(localMigrations []localScript, databaseMigrations []historyModel) (*MigrateResult, error) {
 
 	startExec := time.Now().UnixMilli()
 
-	countMigrations := 0
+	result := &MigrateResult{
+		Migrations: []MigrateOutput{},
+	}
 	executedMigrations := databaseMigrations
 
 	installedRank := findLargestInstalledRank(executedMigrations)
+	if gr.lastInstalledRank > installedRank {
+		installedRank = gr.lastInstalledRank
+	}
 
 	var latestVersion string
 	if len(databaseMigrations) > 0 {
 		latestVersion = databaseMigrations[len(databaseMigrations)-1].Version
 	}
 
+	result.InitialSchemaVersion = latestVersion
+	result.TargetSchemaVersion = latestVersion
+
 	if len(latestVersion) == 0 {
-		logg.Printf("current version of schema: << Empty Schema >>")
+		gr.config.Logger.Printf("current version of schema: << Empty Schema >>")
 	} else {
-		logg.Printf("current version of schema: %s", latestVersion)
+		gr.config.Logger.Printf("current version of schema: %s", latestVersion)
 	}
 
 	for _, lm := range localMigrations {
@@ -354,34 +992,366 @@ func (gr *goFlywayRunner) applyMigrations(localMigrations []localScript, databas
 				Version:       lm.Version,
 				Description:   lm.Description,
 				Script:        lm.Script,
-				Type:          "sql",
+				Type:          gr.migrationType(),
 				Checksum:      lm.Checksum,
+				InstalledBy:   gr.installedBy,
 				InstalledRank: installedRank,
 			}
 
-			_, err := executeMigration(gr.config.Db, parseInsertMigration(gr.config.Driver, gr.config.Table), newMigration, gr)
+			output, err := executeMigration(context.Background(), gr.config.Db, gr.dialect.InsertHistory(gr.config.DefaultSchema, gr.config.Table), newMigration, gr)
 			if err != nil {
-				return countMigrations, throwErrMigration(fmt.Errorf("migration %s failed: %v", newMigration.Script, err))
+				if !supportsTransactionalDDL(gr.dialect) && gr.tx == nil {
+					gr.warn(fmt.Sprintf("warning: %s does not support transactional DDL, migration %s may have been partially applied",
+						gr.config.Driver, newMigration.Script))
+				}
+				return result, throwErrMigration(&ScriptExecutionError{
+					Version: newMigration.Version,
+					Script:  newMigration.Script,
+					Err:     err,
+				})
 			}
 
-			logg.Printf("migrating schema to version %s - %s", newMigration.Version, newMigration.Description)
+			gr.config.Logger.Printf("migrating schema to version %s - %s", newMigration.Version, newMigration.Description)
 
 			executedMigrations = append(executedMigrations, newMigration)
-			countMigrations++
+			result.Migrations = append(result.Migrations, *output)
+			result.MigrationsExecuted++
 
 			latestVersion = newMigration.Version
+			result.TargetSchemaVersion = latestVersion
 		}
 	}
 
 	endExec := time.Now().UnixMilli()
 	executionTime := int(endExec - startExec)
 
-	if countMigrations == 0 {
-		logg.Printf("schema is up to date, no migration necessary")
+	if result.MigrationsExecuted == 0 {
+		gr.config.Logger.Printf("schema is up to date, no migration necessary")
 	} else {
-		logg.Printf("successfully applied %d migrations to schema, now at version v%s (execution time %dms)",
-			countMigrations, latestVersion, executionTime) // TODO format to time
+		gr.config.Logger.Printf("successfully applied %d migrations to schema, now at version v%s (execution time %dms)",
+			result.MigrationsExecuted, latestVersion, executionTime) // TODO format to time
 	}
 
-	return countMigrations, nil
+	return result, nil
+}
+
+// repairHistory Delete the failed rows of the schema history and realign the applied rows with the local migrations,
+// in a single transaction
+func (g *goFlywayRunner) repairHistory(d RepairDialect, localMigrations []localScript, databaseMigrations []historyModel) (*RepairResult, error) {
+
+	result := &RepairResult{
+		MigrationsRemoved: []RepairOutput{},
+		MigrationsAligned: []RepairOutput{},
+	}
+
+	tx, err := g.config.Db.BeginTx(context.Background(), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	deleteQuery := d.DeleteHistory(g.config.DefaultSchema, g.config.Table)
+	updateQuery := d.UpdateHistory(g.config.DefaultSchema, g.config.Table)
+
+	applied := []historyModel{}
+	for _, dm := range databaseMigrations {
+
+		if dm.Success || len(dm.Version) <= 0 {
+			applied = append(applied, dm)
+			continue
+		}
+
+		if _, err = tx.Exec(deleteQuery, g.historyArgs(sql.Named("installed_rank", dm.InstalledRank))...); err != nil {
+			return nil, err
+		}
+		result.MigrationsRemoved = append(result.MigrationsRemoved, RepairOutput{dm.Version, dm.Description, dm.Script})
+	}
+
+	applied, err = g.resolveAppliedMigrations(applied)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, dm := range applied {
+
+		lm := findLocalMigrationByVersion(localMigrations, dm.Version)
+		if lm == nil || strings.EqualFold(dm.Type, flywayTypeBaseline) {
+			continue
+		}
+
+		if dm.Checksum == lm.Checksum && dm.Description == lm.Description {
+			continue
+		}
+
+		checksum, err := g.checksumParam(lm.Checksum)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = tx.Exec(updateQuery, g.historyArgs(
+			sql.Named("checksum", checksum),
+			sql.Named("description", lm.Description),
+			sql.Named("installed_rank", dm.InstalledRank))...)
+		if err != nil {
+			return nil, err
+		}
+		result.MigrationsAligned = append(result.MigrationsAligned, RepairOutput{lm.Version, lm.Description, lm.Script})
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	g.config.Logger.Printf("successfully repaired schema history table, %d failed migrations removed, %d migrations realigned",
+		len(result.MigrationsRemoved), len(result.MigrationsAligned))
+
+	return result, nil
+}
+
+// historyArgs Bind schema history parameters as described by the dialect ParamStyle
+func (g *goFlywayRunner) historyArgs(args ...sql.NamedArg) []interface{} {
+
+	bound := make([]interface{}, len(args))
+	for i, a := range args {
+		if g.dialect.ParamStyle() == ParamNamed {
+			bound[i] = a
+		} else {
+			bound[i] = a.Value
+		}
+	}
+
+	return bound
+}
+
+// resolveInstalledBy Choose the installed_by value of the run: the configured value, the resolver result,
+// or the current database user
+func (g *goFlywayRunner) resolveInstalledBy() error {
+
+	g.installedBy = g.config.InstalledBy
+
+	if len(g.installedBy) <= 0 && g.config.InstalledByResolver != nil {
+		g.installedBy = g.config.InstalledByResolver()
+	}
+
+	if len(g.installedBy) > 0 {
+		return nil
+	}
+
+	g.installedBy = anonymousUser
+
+	d, ok := g.dialect.(CurrentUserDialect)
+	if !ok {
+		return nil
+	}
+
+	var user sql.NullString
+	err := queryRow(context.Background(), g.config.Db, d.CurrentUser(), nil, &user)
+	if err != nil {
+		return throwErrMigration(fmt.Errorf("error reading current database user: %w", err))
+	}
+
+	if user.Valid && len(user.String) > 0 {
+		g.installedBy = user.String
+	}
+
+	return nil
+}
+
+// lock Open the database when configured with DSN, pin the run to a dedicated connection, so session settings persist across scripts, execute InitSQL on it
+// and acquire the migration lock. Returns the function that releases the lock and the connection
+func (g *goFlywayRunner) lock() (func(), error) {
+
+	disconnect, err := g.connect()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+
+	pooled := g.config.Db
+	conn, release, err := sessionConn(ctx, pooled)
+	if err != nil {
+		disconnect()
+		return nil, throwErrMigration(fmt.Errorf("error acquiring migration lock: %w", err))
+	}
+
+	g.config.Db = conn
+
+	if conn != pooled && (g.config.LockTimeout > 0 || g.config.StatementTimeout > 0) {
+		// the session timeouts must not bound the statements of the next user of the pooled connection
+		closeConn := release
+		release = func() {
+			conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+			closeConn()
+		}
+	}
+
+	err = g.initSession(ctx)
+	if err != nil {
+		release()
+		disconnect()
+		return nil, throwErrMigration(fmt.Errorf("error executing init SQL: %w", err))
+	}
+
+	err = g.acquireLock(ctx, conn)
+	if err != nil {
+		release()
+		disconnect()
+		return nil, throwErrMigration(fmt.Errorf("error acquiring migration lock: %w", err))
+	}
+
+	// set after the lock, so waiting for another run is not bounded by the lock timeout of the scripts
+	err = g.sessionTimeouts(ctx)
+	if err != nil {
+		g.dialect.Unlock(ctx, conn, g.config.DefaultSchema, g.config.Table)
+		release()
+		disconnect()
+		return nil, throwErrMigration(fmt.Errorf("error setting session timeouts: %w", err))
+	}
+
+	return func() {
+		err := g.dialect.Unlock(ctx, conn, g.config.DefaultSchema, g.config.Table)
+		if err != nil {
+			g.warn(fmt.Sprintf("warning: error releasing migration lock: %v", err))
+		}
+		release()
+		disconnect()
+	}, nil
+}
+
+// acquireLock Take the migration lock of the dialect. The wait for the lock row of an ExpiringLockDialect, which a
+// crashed run leaves behind, is bounded by LockTimeout, or StatementTimeout when not set
+func (g *goFlywayRunner) acquireLock(ctx context.Context, conn *sql.Conn) error {
+
+	d, ok := g.dialect.(ExpiringLockDialect)
+	if !ok {
+		return g.dialect.Lock(ctx, conn, g.config.DefaultSchema, g.config.Table)
+	}
+
+	wait := g.config.LockTimeout
+	if wait <= 0 {
+		wait = g.config.StatementTimeout
+	}
+
+	if wait > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, wait)
+		defer cancel()
+	}
+
+	staleAge := g.config.StaleLockAge
+	if staleAge == 0 {
+		staleAge = staleLockAgeDefault
+	}
+
+	err := d.LockExpiring(ctx, conn, g.config.DefaultSchema, g.config.Table, staleAge)
+	if err != nil && wait > 0 && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return &TimeoutError{Setting: "migration lock wait", Timeout: wait, Err: err}
+	}
+
+	return err
+}
+
+// initSession Execute the statements of InitSQL on the connection of the run
+func (g *goFlywayRunner) initSession(ctx context.Context) error {
+
+	if len(strings.TrimSpace(g.config.InitSQL)) <= 0 {
+		return nil
+	}
+
+	for _, statement := range g.dialect.SplitStatements(g.config.InitSQL) {
+		if _, err := g.config.Db.ExecContext(ctx, statement); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// sessionTimeouts Set LockTimeout and StatementTimeout on the connection of the run
+func (g *goFlywayRunner) sessionTimeouts(ctx context.Context) error {
+
+	if g.config.LockTimeout <= 0 && g.config.StatementTimeout <= 0 {
+		return nil
+	}
+
+	d, ok := g.dialect.(SessionTimeoutDialect)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrSessionTimeoutNotSupported, g.config.Driver)
+	}
+
+	commands, err := d.SessionTimeouts(g.config.LockTimeout, g.config.StatementTimeout)
+	if err != nil {
+		return err
+	}
+
+	for _, command := range commands {
+		if _, err = g.config.Db.ExecContext(ctx, command); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// historyTableExists Reports whether the schema history table exists, asking the dialect when it is a
+// HistoryTableChecker or probing the table otherwise
+func (g *goFlywayRunner) historyTableExists(ctx context.Context) (bool, error) {
+
+	if checker, ok := g.dialect.(HistoryTableChecker); ok {
+		return checker.HistoryTableExists(ctx, g.config.Db, g.config.DefaultSchema, g.config.Table)
+	}
+
+	_, err := g.config.Db.ExecContext(ctx, fillTemplate(historyTableExists, g.dialect, g.config.DefaultSchema, g.config.Table))
+	if err != nil && ctx.Err() != nil {
+		return false, ctx.Err()
+	}
+
+	return err == nil, nil
+}
+
+// schemas Returns the default schema followed by the other configured schemas
+func (g *goFlywayRunner) schemas() []string {
+
+	schemas := []string{}
+	if len(g.config.DefaultSchema) > 0 {
+		schemas = append(schemas, g.config.DefaultSchema)
+	}
+
+	for _, s := range g.config.Schemas {
+		if s != g.config.DefaultSchema {
+			schemas = append(schemas, s)
+		}
+	}
+
+	return schemas
+}
+
+// versionPattern Returns the pattern versions of script names must match. Flyway also accepts dots as separators
+func (g *goFlywayRunner) versionPattern() *regexp.Regexp {
+
+	if g.config.FlywayCompatible {
+		return regexFlywayVersion
+	}
+
+	return regexVersion
+}
+
+// migrationType Returns the type stored in the schema history table for applied scripts
+func (g *goFlywayRunner) migrationType() string {
+
+	if g.config.FlywayCompatible {
+		return flywayTypeSQL
+	}
+
+	return migrationTypeSql
+}
+
+// warn Record a warning for the current run and print it when warning logs are enabled
+func (g *goFlywayRunner) warn(message string) {
+
+	g.warnings = append(g.warnings, message)
+	if g.config.ShowWarningLog {
+		g.config.Logger.Printf("%s", message)
+	}
 }
@@ -69,6 +69,19 @@ func TestValidateMigrations_DuplicatedVersionError(t *testing.T) {
 	if !errors.As(err, &errMig) {
 		t.Errorf("expected error of type %v but got %v", reflect.TypeOf(errMig), reflect.TypeOf(err))
 	}
+
+	var dupErr *DuplicateVersionError
+	if !errors.As(err, &dupErr) {
+		t.Fatalf("expected error of type %v but got %v", reflect.TypeOf(dupErr), reflect.TypeOf(err))
+	}
+
+	if dupErr.Version != "2" || len(dupErr.Scripts) != 2 {
+		t.Errorf("expected version 2 with 2 scripts but got version %s with scripts %v", dupErr.Version, dupErr.Scripts)
+	}
+
+	if !errors.Is(err, ErrDuplicateVersion) {
+		t.Errorf("expected error to match %v", ErrDuplicateVersion)
+	}
 }
 
 func TestValidateMigrations_ChecksumMismatchError(t *testing.T) {
@@ -103,6 +116,21 @@ func TestValidateMigrations_ChecksumMismatchError(t *testing.T) {
 	if !errors.As(err, &errMig) {
 		t.Errorf("expected error of type %v but got %v", reflect.TypeOf(errMig), reflect.TypeOf(err))
 	}
+
+	var checksumErr *ChecksumMismatchError
+	if !errors.As(err, &checksumErr) {
+		t.Fatalf("expected error of type %v but got %v", reflect.TypeOf(checksumErr), reflect.TypeOf(err))
+	}
+
+	if checksumErr.Script != "V2__test_alter_table_product.sql" ||
+		checksumErr.Expected != "2eaef764b14c8a99535a61a9f4fd4af9428e1905bfa68659e6af2ed2c45d3a02" ||
+		checksumErr.Actual != "ad237d5f6002d5dbad359f98e2ef38dc74bb0e4eb838dafe923cfe7229b5024c" {
+		t.Errorf("unexpected checksum error values %+v", checksumErr)
+	}
+
+	if !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("expected error to match %v", ErrChecksumMismatch)
+	}
 }
 
 func TestValidateMigrations_DescriptionMismatchError(t *testing.T) {
@@ -137,6 +165,19 @@ func TestValidateMigrations_DescriptionMismatchError(t *testing.T) {
 	if !errors.As(err, &errMig) {
 		t.Errorf("expected error of type %v but got %v", reflect.TypeOf(errMig), reflect.TypeOf(err))
 	}
+
+	var descErr *DescriptionMismatchError
+	if !errors.As(err, &descErr) {
+		t.Fatalf("expected error of type %v but got %v", reflect.TypeOf(descErr), reflect.TypeOf(err))
+	}
+
+	if descErr.Expected != "test alter table product" || descErr.Actual != "test update table product" {
+		t.Errorf("unexpected description error values %+v", descErr)
+	}
+
+	if !errors.Is(err, ErrDescriptionMismatch) {
+		t.Errorf("expected error to match %v", ErrDescriptionMismatch)
+	}
 }
 
 func TestValidateMigrations_OutOfOrderError(t *testing.T) {
@@ -174,6 +215,19 @@ func TestValidateMigrations_OutOfOrderError(t *testing.T) {
 	if !errors.As(err, &errMig) {
 		t.Errorf("expected error of type %v but got %v", reflect.TypeOf(errMig), reflect.TypeOf(err))
 	}
+
+	var orderErr *OutOfOrderError
+	if !errors.As(err, &orderErr) {
+		t.Fatalf("expected error of type %v but got %v", reflect.TypeOf(orderErr), reflect.TypeOf(err))
+	}
+
+	if orderErr.Version != "2" || orderErr.Script != "V2__test_alter_table_product.sql" {
+		t.Errorf("unexpected out of order error values %+v", orderErr)
+	}
+
+	if !errors.Is(err, ErrOutOfOrder) {
+		t.Errorf("expected error to match %v", ErrOutOfOrder)
+	}
 }
 
 func TestValidateMigrations_ValidateMissionMigrationError(t *testing.T) {
@@ -211,6 +265,49 @@ func TestValidateMigrations_ValidateMissionMigrationError(t *testing.T) {
 	if !errors.As(err, &errMig) {
 		t.Errorf("expected error of type %v but got %v", reflect.TypeOf(errMig), reflect.TypeOf(err))
 	}
+
+	var missingErr *MissingMigrationError
+	if !errors.As(err, &missingErr) {
+		t.Fatalf("expected error of type %v but got %v", reflect.TypeOf(missingErr), reflect.TypeOf(err))
+	}
+
+	if missingErr.Version != "2" || missingErr.Script != "V2__test_alter_table_product.sql" {
+		t.Errorf("unexpected missing migration error values %+v", missingErr)
+	}
+
+	if !errors.Is(err, ErrMissingMigration) {
+		t.Errorf("expected error to match %v", ErrMissingMigration)
+	}
+}
+
+func TestScriptExecutionError(t *testing.T) {
+
+	driverErr := errors.New("syntax error at or near \"CREAT\"")
+
+	err := throwErrMigration(&ScriptExecutionError{
+		Version: "1",
+		Script:  "V1__test_create_table_product.sql",
+		Err:     fmt.Errorf("error inserting migration history: %w", driverErr),
+	})
+
+	expectedMessage := "migration V1__test_create_table_product.sql failed: error inserting migration history: syntax error at or near \"CREAT\""
+
+	if err.Error() != expectedMessage {
+		t.Errorf("expected error %v but got %v", expectedMessage, err.Error())
+	}
+
+	var execErr *ScriptExecutionError
+	if !errors.As(err, &execErr) {
+		t.Fatalf("expected error of type %v but got %v", reflect.TypeOf(execErr), reflect.TypeOf(err))
+	}
+
+	if !errors.Is(err, ErrScriptExecution) {
+		t.Errorf("expected error to match %v", ErrScriptExecution)
+	}
+
+	if !errors.Is(err, driverErr) {
+		t.Errorf("expected error to wrap driver error %v", driverErr)
+	}
 }
 
 func TestReadLocalMigrations(t *testing.T) {
@@ -331,6 +428,33 @@ func TestCalculateChecksum(t *testing.T) {
 	}
 }
 
+func TestInstalledByFromCI(t *testing.T) {
+
+	for _, env := range ciInstalledByVariables {
+		t.Setenv(env, "")
+	}
+
+	// Bitbucket only exposes the account UUID of the triggerer, not a user name
+	t.Setenv("BITBUCKET_STEP_TRIGGERER_UUID", "{6a1c1c4e-3f2b-4c41-9a8d-2b0f5c7d9e10}")
+
+	if res := InstalledByFromCI(); len(res) > 0 {
+		t.Errorf("expected empty installed_by outside CI but got %s", res)
+	}
+
+	t.Setenv("GITLAB_USER_LOGIN", "gitlab-user")
+	t.Setenv("GITHUB_ACTOR", "github-user")
+
+	if res := InstalledByFromCI(); res != "github-user" {
+		t.Errorf("expected installed_by github-user but got %s", res)
+	}
+
+	t.Setenv("GOFLYWAY_INSTALLED_BY", "release-pipeline")
+
+	if res := InstalledByFromCI(); res != "release-pipeline" {
+		t.Errorf("expected installed_by release-pipeline but got %s", res)
+	}
+}
+
 func getDatabaseMigrations() []historyModel {
 	currentTime := time.Now()
 	dbMigrations := []historyModel{
@@ -0,0 +1,130 @@
+package goflyway
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// AppliedMigration a row of the schema history table
+type AppliedMigration struct {
+	InstalledRank int
+	Version       string
+	Description   string
+	Type          string
+	Script        string
+	Checksum      string
+	InstalledBy   string
+
+	// Zero when the database value could not be read
+	InstalledOn   time.Time
+	ExecutionTime time.Duration
+	Success       bool
+}
+
+// HistoryFilter selects the rows returned by History. The zero value selects all rows
+type HistoryFilter struct {
+	// Lowest version included, rows without version are excluded when set
+	FromVersion string
+
+	// Highest version included, rows without version are excluded when set
+	ToVersion string
+
+	// Rows installed at or after this time, rows without installation time are excluded when set
+	InstalledFrom time.Time
+
+	// Rows installed before this time, rows without installation time are excluded when set
+	InstalledTo time.Time
+}
+
+// History returns the rows of the schema history table ordered by installed_rank, see Flyway.History
+func History(ctx context.Context, c GoFlywayConfig, filter HistoryFilter) ([]AppliedMigration, error) {
+
+	f, err := New(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return f.History(ctx, filter)
+}
+
+// History returns the rows of the schema history table matching filter, ordered by installed_rank. All rows are
+// returned, including failed migrations and the Flyway schema and delete markers. The database is not changed:
+// when the schema history table does not exist the result is empty
+func (f *Flyway) History(ctx context.Context, filter HistoryFilter) ([]AppliedMigration, error) {
+
+	g := f.runner()
+
+	disconnect, err := g.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer disconnect()
+
+	db := g.config.Db
+
+	applied := []AppliedMigration{}
+
+	exists, err := g.historyTableExists(ctx)
+	if err != nil {
+		return nil, throwErrMigration(err)
+	}
+
+	if !exists {
+		return applied, nil
+	}
+
+	rows, err := selectMigrationHistory(ctx, db, g.dialect.SelectHistory(g.config.DefaultSchema, g.config.Table), g)
+	if err != nil {
+		return nil, throwErrMigration(err)
+	}
+
+	for _, m := range rows {
+
+		a := AppliedMigration{
+			InstalledRank: m.InstalledRank,
+			Version:       m.Version,
+			Description:   m.Description,
+			Type:          m.Type,
+			Script:        m.Script,
+			Checksum:      m.Checksum,
+			InstalledBy:   m.InstalledBy,
+			ExecutionTime: time.Duration(m.ExecutionTime) * time.Millisecond,
+			Success:       m.Success,
+		}
+		if m.InstalledOn != nil {
+			a.InstalledOn = *m.InstalledOn
+		}
+
+		if filter.matches(a) {
+			applied = append(applied, a)
+		}
+	}
+
+	sort.SliceStable(applied, func(i, j int) bool {
+		return applied[i].InstalledRank < applied[j].InstalledRank
+	})
+
+	return applied, nil
+}
+
+func (f HistoryFilter) matches(a AppliedMigration) bool {
+
+	if len(f.FromVersion) > 0 && (len(a.Version) <= 0 || compareVersions(a.Version, f.FromVersion) < 0) {
+		return false
+	}
+
+	if len(f.ToVersion) > 0 && (len(a.Version) <= 0 || compareVersions(a.Version, f.ToVersion) > 0) {
+		return false
+	}
+
+	if !f.InstalledFrom.IsZero() && (a.InstalledOn.IsZero() || a.InstalledOn.Before(f.InstalledFrom)) {
+		return false
+	}
+
+	if !f.InstalledTo.IsZero() && (a.InstalledOn.IsZero() || !a.InstalledOn.Before(f.InstalledTo)) {
+		return false
+	}
+
+	return true
+}
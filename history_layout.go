@@ -0,0 +1,98 @@
+package goflyway
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// layoutTableName Returns the name of the table holding the layout version of the schema history table
+func layoutTableName(table string) string {
+	return table + "_layout"
+}
+
+// upgradeHistoryLayout Bring a schema history table created by a previous GoFlyway version to historyLayoutVersion.
+// Tables without recorded layout are assumed to have layout 1 when they exist, new tables are created with the
+// current layout. The layout version is recorded after each upgrade step
+func (g *goFlywayRunner) upgradeHistoryLayout(d HistoryUpgradeDialect) error {
+
+	db := g.config.Db
+	schema := g.config.DefaultSchema
+	layoutTable := layoutTableName(g.config.Table)
+
+	for _, statement := range g.dialect.SplitStatements(d.CreateLayoutTable(schema, layoutTable)) {
+		if _, err := db.ExecContext(context.Background(), statement); err != nil {
+			return err
+		}
+	}
+
+	version, err := g.readLayoutVersion(layoutTable)
+	if err != nil {
+		return err
+	}
+
+	for version < historyLayoutVersion {
+
+		version++
+
+		g.config.Logger.Printf("upgrading schema history table %s to layout version %d", g.config.Table, version)
+
+		for _, statement := range d.UpgradeHistoryTable(schema, g.config.Table, version) {
+			if _, err = db.ExecContext(context.Background(), statement); err != nil {
+				return fmt.Errorf("error upgrading schema history table to layout version %d: %w", version, err)
+			}
+		}
+
+		if err = g.writeLayoutVersion(layoutTable, updateLayoutVersion, version); err != nil {
+			return err
+		}
+	}
+
+	if version > historyLayoutVersion {
+		g.warn(fmt.Sprintf("warning: schema history table %s has layout version %d, newer than %d supported by this GoFlyway version",
+			g.config.Table, version, historyLayoutVersion))
+	}
+
+	return nil
+}
+
+// readLayoutVersion Returns the recorded layout version, recording it first when the layout table is new
+func (g *goFlywayRunner) readLayoutVersion(layoutTable string) (int, error) {
+
+	query := fillTemplate(selectLayoutVersion, g.dialect, g.config.DefaultSchema, layoutTable)
+
+	var version int
+	err := queryRow(context.Background(), g.config.Db, query, nil, &version)
+	if err == nil {
+		return version, nil
+	}
+
+	if !errors.Is(err, sql.ErrNoRows) {
+		return 0, err
+	}
+
+	version = historyLayoutVersion
+
+	// a schema history table without layout was created before layouts were recorded
+	exists, err := g.historyTableExists(context.Background())
+	if err != nil {
+		return 0, err
+	}
+
+	if exists {
+		version = 1
+	}
+
+	return version, g.writeLayoutVersion(layoutTable, insertLayoutVersion, version)
+}
+
+func (g *goFlywayRunner) writeLayoutVersion(layoutTable string, template string, version int) error {
+
+	query := fillTemplate(template, g.dialect, g.config.DefaultSchema, layoutTable)
+
+	_, err := g.config.Db.ExecContext(context.Background(), regexLayoutVersion.ReplaceAllLiteralString(query, strconv.Itoa(version)))
+
+	return err
+}
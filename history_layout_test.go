@@ -0,0 +1,80 @@
+package goflyway
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// upgradeSqlite3Dialect A sqlite dialect whose layout 2 upgrade adds a column
+type upgradeSqlite3Dialect struct {
+	sqlite3Dialect
+}
+
+func (d *upgradeSqlite3Dialect) UpgradeHistoryTable(schema string, table string, version int) []string {
+
+	if version == 2 {
+		return []string{fillTemplate(`ALTER TABLE [tableName] ADD COLUMN upgraded INT`, d, schema, table)}
+	}
+
+	return nil
+}
+
+func TestFakeExecutorHistoryLayoutNewTable(t *testing.T) {
+
+	f := newFakeExecutor()
+	conf := getFakeConfig(f)
+	f.failOn("WHERE 1 = 0", errors.New("no such table: goflyway_schema_history"))
+
+	if _, err := Migrate(conf); err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	if s := f.executedContaining(fmt.Sprintf("(layout_version) VALUES (%d)", historyLayoutVersion)); len(s) != 1 {
+		t.Errorf("expected layout version %d to be recorded once but got %q", historyLayoutVersion, s)
+	}
+
+	if s := f.executedContaining("SET layout_version"); len(s) > 0 {
+		t.Errorf("expected a new table not to be upgraded but got %q", s)
+	}
+}
+
+func TestFakeExecutorHistoryLayoutUpgrade(t *testing.T) {
+
+	RegisterDialect("sqlite3-upgrade", &upgradeSqlite3Dialect{})
+
+	// the schema history table exists without recorded layout
+	f := newFakeExecutor()
+	conf := getFakeConfig(f)
+	conf.Driver = "sqlite3-upgrade"
+
+	if _, err := Migrate(conf); err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	if s := f.executedContaining("(layout_version) VALUES (1)"); len(s) != 1 {
+		t.Errorf("expected legacy layout 1 to be recorded but got %q", s)
+	}
+
+	if s := f.executedContaining("ADD COLUMN upgraded"); len(s) != 1 {
+		t.Errorf("expected the history table to be upgraded once but got %q", s)
+	}
+
+	if s := f.executedContaining(fmt.Sprintf("SET layout_version = %d", historyLayoutVersion)); len(s) != 1 {
+		t.Errorf("expected layout version %d to be recorded but got %q", historyLayoutVersion, s)
+	}
+}
+
+func TestUpgradeHistoryTable(t *testing.T) {
+
+	query := (&postgresDialect{}).UpgradeHistoryTable("app", "history", 2)
+	if len(query) != 1 || !strings.Contains(query[0], `ALTER TABLE "app"."history" RENAME CONSTRAINT pk_goflyway_sch_hist TO "history_pk"`) {
+		t.Errorf("expected primary key rename but got %v", query)
+	}
+
+	query = (&sqlServerDialect{}).UpgradeHistoryTable("app", "it's", 2)
+	if len(query) != 1 || !strings.Contains(query[0], `EXEC sp_rename '[app].[pk_goflyway_sch_hist]', 'it''s_pk', 'OBJECT'`) {
+		t.Errorf("expected primary key rename but got %v", query)
+	}
+}
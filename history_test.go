@@ -0,0 +1,38 @@
+package goflyway
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHistoryFilterMatches(t *testing.T) {
+
+	installed := time.Date(2024, 5, 2, 10, 0, 0, 0, time.UTC)
+	migration := AppliedMigration{Version: "2", InstalledOn: installed}
+	unknown := AppliedMigration{Version: "2"}
+
+	tests := []struct {
+		name     string
+		filter   HistoryFilter
+		applied  AppliedMigration
+		expected bool
+	}{
+		{"zero filter", HistoryFilter{}, migration, true},
+		{"zero filter unknown time", HistoryFilter{}, unknown, true},
+		{"from version", HistoryFilter{FromVersion: "3"}, migration, false},
+		{"to version", HistoryFilter{ToVersion: "2"}, migration, true},
+		{"no version", HistoryFilter{ToVersion: "2"}, AppliedMigration{InstalledOn: installed}, false},
+		{"installed from", HistoryFilter{InstalledFrom: installed}, migration, true},
+		{"installed from after", HistoryFilter{InstalledFrom: installed.Add(time.Second)}, migration, false},
+		{"installed to", HistoryFilter{InstalledTo: installed}, migration, false},
+		{"installed to after", HistoryFilter{InstalledTo: installed.Add(time.Second)}, migration, true},
+		{"installed from unknown time", HistoryFilter{InstalledFrom: installed}, unknown, false},
+		{"installed to unknown time", HistoryFilter{InstalledTo: installed}, unknown, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.filter.matches(tt.applied); got != tt.expected {
+			t.Errorf("%s: expected %v but got %v", tt.name, tt.expected, got)
+		}
+	}
+}
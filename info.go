@@ -0,0 +1,147 @@
+package goflyway
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MigrationState state of a migration reported by Info
+type MigrationState string
+
+const (
+	// StatePending the local migration was not applied yet
+	StatePending MigrationState = "Pending"
+
+	// StateSuccess the migration was applied successfully
+	StateSuccess MigrationState = "Success"
+
+	// StateFailed the migration failed, run Repair after removing any half-completed changes
+	StateFailed MigrationState = "Failed"
+
+	// StateMissing the migration was applied but is not resolved locally anymore
+	StateMissing MigrationState = "Missing"
+
+	// StateBaseline the schema history starts at this version
+	StateBaseline MigrationState = "Baseline"
+
+	// StateBelowBaseline the local migration is at or below the baseline version and will not be applied
+	StateBelowBaseline MigrationState = "Below Baseline"
+)
+
+// MigrationInfo a migration resolved locally, applied to the database, or both
+type MigrationInfo struct {
+	Version       string
+	Description   string
+	Type          string
+	Script        string
+	InstalledBy   string
+	InstalledOn   *time.Time
+	ExecutionTime time.Duration
+	State         MigrationState
+}
+
+// Info lists the applied and pending migrations ordered by version. It only reads the database and takes no lock,
+// every migration is pending when the schema history table does not exist
+func (f *Flyway) Info() ([]MigrationInfo, error) {
+
+	g := f.runner()
+
+	disconnect, err := g.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer disconnect()
+
+	mFiles, err := g.readLocalMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+
+	exists, err := g.historyTableExists(ctx)
+	if err != nil {
+		return nil, throwErrMigration(fmt.Errorf("error reading migration table: %w", err))
+	}
+
+	mTable := []historyModel{}
+	if exists {
+		mTable, err = selectMigrationHistory(ctx, g.config.Db, g.dialect.SelectHistory(g.config.DefaultSchema, g.config.Table), g)
+		if err != nil {
+			return nil, throwErrMigration(fmt.Errorf("error reading migration table: %w", err))
+		}
+	}
+
+	applied := []historyModel{}
+	failed := []historyModel{}
+	for _, m := range mTable {
+		if !m.Success && len(m.Version) > 0 {
+			failed = append(failed, m)
+		} else {
+			applied = append(applied, m)
+		}
+	}
+
+	applied, err = g.resolveAppliedMigrations(applied)
+	if err != nil {
+		return nil, throwErrMigration(fmt.Errorf("error reading migration table: %w", err))
+	}
+
+	baseline := baselineVersion(applied)
+	infos := []MigrationInfo{}
+
+	for _, m := range append(applied, failed...) {
+
+		info := MigrationInfo{
+			Version:       m.Version,
+			Description:   m.Description,
+			Type:          m.Type,
+			Script:        m.Script,
+			InstalledBy:   m.InstalledBy,
+			InstalledOn:   m.InstalledOn,
+			ExecutionTime: time.Duration(m.ExecutionTime) * time.Millisecond,
+			State:         StateSuccess,
+		}
+
+		switch {
+		case !m.Success:
+			info.State = StateFailed
+		case strings.EqualFold(m.Type, flywayTypeBaseline):
+			info.State = StateBaseline
+		case findLocalMigrationByVersion(mFiles, m.Version) == nil:
+			info.State = StateMissing
+		}
+
+		infos = append(infos, info)
+	}
+
+	for _, lm := range mFiles {
+
+		if findMigrationByVersion(applied, lm.Version) != nil || findMigrationByVersion(failed, lm.Version) != nil {
+			continue
+		}
+
+		info := MigrationInfo{
+			Version:     lm.Version,
+			Description: lm.Description,
+			Type:        g.migrationType(),
+			Script:      lm.Script,
+			State:       StatePending,
+		}
+
+		if len(baseline) > 0 && compareVersions(lm.Version, baseline) <= 0 {
+			info.State = StateBelowBaseline
+		}
+
+		infos = append(infos, info)
+	}
+
+	sort.SliceStable(infos, func(i, j int) bool {
+		return compareVersions(infos[i].Version, infos[j].Version) < 0
+	})
+
+	return infos, nil
+}
@@ -0,0 +1,88 @@
+//go:build integration
+
+package integration
+
+import (
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/gabrielaraujosouza/goflyway"
+	_ "github.com/go-sql-driver/mysql"
+)
+
+// Runs against the mariadb service of utils/docker-compose.yml:
+//
+//	docker compose -f utils/docker-compose.yml up -d mariadb
+//	cd integration && go test -tags integration -run MariaDb ./...
+func openMariaDb(t *testing.T) *sql.DB {
+
+	dsn := os.Getenv("GOFLYWAY_MARIADB_DSN")
+	if len(dsn) <= 0 {
+		dsn = "root:root@tcp(localhost:3307)/goflyway"
+	}
+
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		t.Fatalf("errors happened when opening database: %v", err)
+	}
+
+	if err = db.Ping(); err != nil {
+		t.Skipf("mariadb is not available: %v", err)
+	}
+
+	t.Cleanup(func() {
+		db.Close()
+	})
+
+	return db
+}
+
+func getMariaDbConfig(db *sql.DB) goflyway.GoFlywayConfig {
+	return goflyway.GoFlywayConfig{
+		Db:       db,
+		Driver:   goflyway.MARIADB,
+		Location: "../utils/test/db/migration/mysql",
+	}
+}
+
+func TestMariaDbMigrate(t *testing.T) {
+
+	db := openMariaDb(t)
+	conf := getMariaDbConfig(db)
+	conf.CleanEnabled = true
+
+	if err := goflyway.Clean(conf); err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	result, err := goflyway.Migrate(conf)
+	if err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	if result.MigrationsExecuted != 3 || result.TargetSchemaVersion != "3" {
+		t.Errorf("expected 3 migrations up to version 3 but got %d up to version %s",
+			result.MigrationsExecuted, result.TargetSchemaVersion)
+	}
+
+	var installedBy string
+	err = db.QueryRow("SELECT installed_by FROM goflyway_schema_history WHERE version = '1'").Scan(&installedBy)
+	if err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	if installedBy != "root" {
+		t.Errorf("expected installed_by root but got %s", installedBy)
+	}
+
+	// history table already exists, nothing left to apply
+	result, err = goflyway.Migrate(conf)
+	if err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	if result.MigrationsExecuted != 0 {
+		t.Errorf("expected no migration but got %d", result.MigrationsExecuted)
+	}
+}
@@ -0,0 +1,851 @@
+package integration
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gabrielaraujosouza/goflyway"
+	_ "modernc.org/sqlite"
+)
+
+// flywayTableName and flywayTypeSQL are the history table and migration type of Java Flyway
+const (
+	flywayTableName = "flyway_schema_history"
+	flywayTypeSQL   = "SQL"
+)
+
+// openSqlite3 Opens an in-memory database private to the test. The shared cache keeps the
+// database alive across the connections of the pool
+func openSqlite3(t *testing.T) *sql.DB {
+
+	db, err := sql.Open("sqlite", fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name()))
+	if err != nil {
+		t.Fatalf("errors happened when opening database: %v", err)
+	}
+
+	t.Cleanup(func() {
+		db.Close()
+	})
+
+	return db
+}
+
+func getSqlite3Config(db *sql.DB) goflyway.GoFlywayConfig {
+	return goflyway.GoFlywayConfig{
+		Db:       db,
+		Driver:   goflyway.SQLITE3,
+		Location: "../utils/test/db/migration/sqlite3",
+	}
+}
+
+func TestSqlite3Migrate(t *testing.T) {
+
+	db := openSqlite3(t)
+	conf := getSqlite3Config(db)
+
+	result, err := goflyway.Migrate(conf)
+	if err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	if result.InitialSchemaVersion != "" || result.TargetSchemaVersion != "3" {
+		t.Errorf("expected migration from empty schema to version 3 but got %s to %s",
+			result.InitialSchemaVersion, result.TargetSchemaVersion)
+	}
+
+	if result.MigrationsExecuted != 3 || len(result.Migrations) != 3 {
+		t.Fatalf("expected 3 migrations but got %d", result.MigrationsExecuted)
+	}
+
+	if result.Migrations[0].Script != "V1__test_create_table_product.sql" {
+		t.Errorf("expected first migration V1__test_create_table_product.sql but got %s", result.Migrations[0].Script)
+	}
+
+	result, err = goflyway.Migrate(conf)
+	if err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	if result.MigrationsExecuted != 0 || result.InitialSchemaVersion != "3" {
+		t.Errorf("expected no migration from version 3 but got %d from version %s",
+			result.MigrationsExecuted, result.InitialSchemaVersion)
+	}
+}
+
+func TestSqlite3HistoryInstalledOn(t *testing.T) {
+
+	db := openSqlite3(t)
+	conf := getSqlite3Config(db)
+
+	logger := &recordLogger{}
+	conf.Logger = logger
+	conf.ShowWarningLog = true
+
+	if _, err := goflyway.Migrate(conf); err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	migrations, err := goflyway.History(context.Background(), conf, goflyway.HistoryFilter{})
+	if err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	if len(migrations) != 3 {
+		t.Fatalf("expected 3 applied migrations but got %d", len(migrations))
+	}
+
+	for _, m := range migrations {
+		if m.InstalledOn.IsZero() {
+			t.Errorf("expected installed_on for migration %s but got none", m.Version)
+		}
+
+		if m.InstalledBy != "anonymous" || !m.Success {
+			t.Errorf("unexpected history row %+v", m)
+		}
+	}
+
+	for _, l := range logger.logs {
+		if strings.Contains(l, "warning") {
+			t.Errorf("expected no warnings but got %s", l)
+		}
+	}
+}
+
+func TestSqlite3Migrate_ChecksumMismatch(t *testing.T) {
+
+	db := openSqlite3(t)
+	conf := getSqlite3Config(db)
+
+	if _, err := goflyway.Migrate(conf); err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	_, err := db.Exec(`UPDATE goflyway_schema_history SET checksum = 'changed' WHERE version = '2'`)
+	if err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	_, err = goflyway.Migrate(conf)
+	if !errors.Is(err, goflyway.ErrChecksumMismatch) {
+		t.Errorf("expected error %v but got %v", goflyway.ErrChecksumMismatch, err)
+	}
+}
+
+func TestSqlite3Migrate_ScriptExecutionError(t *testing.T) {
+
+	db := openSqlite3(t)
+	conf := getSqlite3Config(db)
+
+	// version 2 adds a column that already exists
+	_, err := db.Exec(`CREATE TABLE product (id VARCHAR(36) NOT NULL, name VARCHAR(255), code VARCHAR(100), description VARCHAR(255))`)
+	if err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	result, err := goflyway.Migrate(conf)
+
+	var execErr *goflyway.ScriptExecutionError
+	if !errors.As(err, &execErr) {
+		t.Fatalf("expected error of type ScriptExecutionError but got %v", err)
+	}
+
+	if execErr.Version != "2" {
+		t.Errorf("expected failed version 2 but got %s", execErr.Version)
+	}
+
+	if result == nil || result.MigrationsExecuted != 1 || result.TargetSchemaVersion != "1" {
+		t.Errorf("expected partial result up to version 1 but got %+v", result)
+	}
+}
+
+func TestSqlite3NewMigration_AfterV9(t *testing.T) {
+
+	db := openSqlite3(t)
+	conf := getSqlite3Config(db)
+	conf.Location = t.TempDir()
+
+	for i := 1; i <= 9; i++ {
+		script := fmt.Sprintf("%s/V%d__create_table_%d.sql", conf.Location, i, i)
+		if err := os.WriteFile(script, []byte(fmt.Sprintf("CREATE TABLE t%d (id INT);", i)), 0o644); err != nil {
+			t.Fatalf("errors happened when writing script: %v", err)
+		}
+	}
+
+	if _, err := goflyway.Migrate(conf); err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	// V10 sorts after V9, and a timestamp after V10
+	for _, strategy := range []goflyway.VersionStrategy{goflyway.VersionIncrement, goflyway.VersionTimestamp} {
+
+		conf.VersionStrategy = strategy
+
+		path, err := goflyway.NewMigration(conf, "next "+string(strategy))
+		if err != nil {
+			t.Fatalf("%s: expected nil but got error %v", strategy, err)
+		}
+
+		if strategy == goflyway.VersionIncrement && !strings.HasSuffix(path, "V10__next_increment.sql") {
+			t.Errorf("expected V10__next_increment.sql but got %s", path)
+		}
+
+		if err = os.WriteFile(path, []byte("CREATE TABLE t_"+string(strategy)+" (id INT);"), 0o644); err != nil {
+			t.Fatalf("errors happened when writing script: %v", err)
+		}
+
+		result, err := goflyway.Migrate(conf)
+		if err != nil {
+			t.Fatalf("%s: expected nil but got error %v", strategy, err)
+		}
+
+		if result.MigrationsExecuted != 1 {
+			t.Errorf("%s: expected 1 migration but got %d", strategy, result.MigrationsExecuted)
+		}
+	}
+
+	result, err := goflyway.Migrate(conf)
+	if err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	if result.MigrationsExecuted != 0 || len(result.InitialSchemaVersion) != 14 {
+		t.Errorf("expected no migration from the timestamp version but got %d from version %s",
+			result.MigrationsExecuted, result.InitialSchemaVersion)
+	}
+}
+
+func TestSqlite3Clean(t *testing.T) {
+
+	db := openSqlite3(t)
+	conf := getSqlite3Config(db)
+	conf.CleanEnabled = true
+
+	if _, err := goflyway.Migrate(conf); err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	if err := goflyway.Clean(conf); err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	var total int
+	err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type IN ('table', 'view')`).Scan(&total)
+	if err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	if total != 0 {
+		t.Errorf("expected empty database after clean but got %d objects", total)
+	}
+}
+
+func TestSqlite3Migrate_InstalledBy(t *testing.T) {
+
+	type InstalledByExpected struct {
+		Config   func(c *goflyway.GoFlywayConfig)
+		Expected string
+	}
+
+	cases := []InstalledByExpected{
+		{Config: func(c *goflyway.GoFlywayConfig) {}, Expected: "anonymous"},
+		{Config: func(c *goflyway.GoFlywayConfig) { c.InstalledBy = "deploy-bot" }, Expected: "deploy-bot"},
+		{Config: func(c *goflyway.GoFlywayConfig) { c.InstalledByResolver = func() string { return "jane" } }, Expected: "jane"},
+		{Config: func(c *goflyway.GoFlywayConfig) {
+			c.InstalledBy = "deploy-bot"
+			c.InstalledByResolver = func() string { return "jane" }
+		}, Expected: "deploy-bot"},
+	}
+
+	for i, c := range cases {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+
+			db := openSqlite3(t)
+			conf := getSqlite3Config(db)
+			c.Config(&conf)
+
+			if _, err := goflyway.Migrate(conf); err != nil {
+				t.Fatalf("expected nil but got error %v", err)
+			}
+
+			var installedBy string
+			err := db.QueryRow(`SELECT DISTINCT installed_by FROM goflyway_schema_history`).Scan(&installedBy)
+			if err != nil {
+				t.Fatalf("expected nil but got error %v", err)
+			}
+
+			if installedBy != c.Expected {
+				t.Errorf("expected installed_by %s but got %s", c.Expected, installedBy)
+			}
+		})
+	}
+}
+
+func TestSqlite3Repair(t *testing.T) {
+
+	db := openSqlite3(t)
+	conf := getSqlite3Config(db)
+
+	if _, err := goflyway.Migrate(conf); err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	_, err := db.Exec(`INSERT INTO goflyway_schema_history
+		(installed_rank, version, description, type, script, checksum, installed_by, execution_time, success)
+		VALUES (4, '4', 'failed', 'sql', 'V4__failed.sql', 'x', 'test', 0, 0)`)
+	if err != nil {
+		t.Fatalf("errors happened when inserting failed migration: %v", err)
+	}
+
+	conf.ChecksumAlgorithm = goflyway.ChecksumCRC32
+
+	result, err := goflyway.Repair(conf)
+	if err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	if len(result.MigrationsRemoved) != 1 || result.MigrationsRemoved[0].Version != "4" {
+		t.Errorf("expected failed migration 4 to be removed but got %v", result.MigrationsRemoved)
+	}
+
+	if len(result.MigrationsAligned) != 3 {
+		t.Errorf("expected 3 realigned migrations but got %v", result.MigrationsAligned)
+	}
+
+	migrateResult, err := goflyway.Migrate(conf)
+	if err != nil {
+		t.Fatalf("expected nil after repair but got error %v", err)
+	}
+
+	if migrateResult.MigrationsExecuted != 0 {
+		t.Errorf("expected no migration but got %d", migrateResult.MigrationsExecuted)
+	}
+
+	result, err = goflyway.Repair(conf)
+	if err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	if len(result.MigrationsRemoved) != 0 || len(result.MigrationsAligned) != 0 {
+		t.Errorf("expected nothing to repair but got %v", result)
+	}
+}
+
+func TestSqlite3Info(t *testing.T) {
+
+	db := openSqlite3(t)
+	conf := getSqlite3Config(db)
+
+	infos, err := goflyway.Info(conf)
+	if err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	if len(infos) != 3 || infos[0].State != goflyway.StatePending {
+		t.Fatalf("expected 3 pending migrations but got %v", infos)
+	}
+
+	var tables int
+	err = db.QueryRow(`SELECT count(*) FROM sqlite_master WHERE name = 'goflyway_schema_history'`).Scan(&tables)
+	if err != nil || tables != 0 {
+		t.Fatalf("expected Info not to create the history table but got %d tables, error %v", tables, err)
+	}
+
+	if _, err = goflyway.Migrate(conf); err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	_, err = db.Exec(`INSERT INTO goflyway_schema_history
+		(installed_rank, version, description, type, script, checksum, installed_by, execution_time, success)
+		VALUES (4, '4', 'failed', 'sql', 'V4__failed.sql', 'x', 'test', 0, 0)`)
+	if err != nil {
+		t.Fatalf("errors happened when inserting failed migration: %v", err)
+	}
+
+	infos, err = goflyway.Info(conf)
+	if err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	expected := []goflyway.MigrationState{goflyway.StateSuccess, goflyway.StateSuccess, goflyway.StateSuccess, goflyway.StateFailed}
+	if len(infos) != len(expected) {
+		t.Fatalf("expected %d migrations but got %v", len(expected), infos)
+	}
+
+	for i, state := range expected {
+		if infos[i].State != state {
+			t.Errorf("expected migration %s in state %s but got %s", infos[i].Version, state, infos[i].State)
+		}
+	}
+
+	if infos[0].InstalledOn == nil {
+		t.Errorf("expected installed_on of applied migration")
+	}
+}
+
+func TestSqlite3Baseline(t *testing.T) {
+
+	db := openSqlite3(t)
+	conf := getSqlite3Config(db)
+	conf.BaselineVersion = "2"
+
+	if err := goflyway.Baseline(conf); err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	if err := goflyway.Baseline(conf); !errors.Is(err, goflyway.ErrBaselineNotEmpty) {
+		t.Errorf("expected ErrBaselineNotEmpty but got %v", err)
+	}
+
+	infos, err := goflyway.Info(conf)
+	if err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	expected := []goflyway.MigrationState{goflyway.StateBelowBaseline, goflyway.StateBaseline, goflyway.StatePending}
+	for i, state := range expected {
+		if infos[i].State != state {
+			t.Errorf("expected migration %s in state %s but got %s", infos[i].Version, state, infos[i].State)
+		}
+	}
+
+	// V1 creates the product table altered by V2 and V3, the baselined database already has it
+	if _, err = db.Exec(`CREATE TABLE product (id INT, name TEXT, description TEXT)`); err != nil {
+		t.Fatalf("errors happened when creating table: %v", err)
+	}
+
+	if err = goflyway.Validate(conf); err != nil {
+		t.Errorf("expected nil but got error %v", err)
+	}
+
+	result, err := goflyway.Migrate(conf)
+	if err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	if result.InitialSchemaVersion != "2" || result.MigrationsExecuted != 1 {
+		t.Errorf("expected 1 migration from baseline version 2 but got %d from version %s",
+			result.MigrationsExecuted, result.InitialSchemaVersion)
+	}
+}
+
+func TestSqlite3Validate(t *testing.T) {
+
+	db := openSqlite3(t)
+	conf := getSqlite3Config(db)
+
+	if _, err := goflyway.Migrate(conf); err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	if _, err := db.Exec(`UPDATE goflyway_schema_history SET checksum = 'x' WHERE version = '2'`); err != nil {
+		t.Fatalf("errors happened when updating checksum: %v", err)
+	}
+
+	if err := goflyway.Validate(conf); !errors.Is(err, goflyway.ErrChecksumMismatch) {
+		t.Errorf("expected ErrChecksumMismatch but got %v", err)
+	}
+}
+
+func TestSqlite3MigrateTx(t *testing.T) {
+
+	db := openSqlite3(t)
+	conf := getSqlite3Config(db)
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	result, err := goflyway.MigrateTx(conf, tx)
+	if err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	if result.MigrationsExecuted != 3 {
+		t.Fatalf("expected 3 migrations but got %d", result.MigrationsExecuted)
+	}
+
+	if err = tx.Rollback(); err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	applied, err := goflyway.History(context.Background(), conf, goflyway.HistoryFilter{})
+	if err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	if len(applied) != 0 {
+		t.Errorf("expected rolled back migrations to leave no history but got %v", applied)
+	}
+
+	tx, err = db.Begin()
+	if err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	if _, err = goflyway.MigrateTx(conf, tx); err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	result, err = goflyway.Migrate(conf)
+	if err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	if result.InitialSchemaVersion != "3" || result.MigrationsExecuted != 0 {
+		t.Errorf("expected committed migrations up to version 3 but got %q with %d pending",
+			result.InitialSchemaVersion, result.MigrationsExecuted)
+	}
+}
+
+func TestSqlite3MigrateSingleConnection(t *testing.T) {
+
+	db := openSqlite3(t)
+	db.SetMaxOpenConns(1)
+
+	conf := getSqlite3Config(db)
+	conf.InitSQL = "CREATE TEMP TABLE init_marker(id INTEGER)"
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := goflyway.Migrate(conf)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("expected nil but got error %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("expected the run to hold a single connection but it is waiting for another one")
+	}
+
+	// temporary tables live in the session of the connection that ran InitSQL
+	var total int
+	if err := db.QueryRow("SELECT COUNT(*) FROM temp.init_marker").Scan(&total); err != nil {
+		t.Errorf("expected InitSQL to run on the pooled connection but got error %v", err)
+	}
+}
+
+func TestSqlite3MigrateDSN(t *testing.T) {
+
+	// keeps the in-memory database alive between the connections opened from DSN
+	db := openSqlite3(t)
+	if err := db.Ping(); err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	conf := getSqlite3Config(nil)
+	conf.Db = nil
+	conf.DSN = "file:" + t.Name() + "?mode=memory&cache=shared"
+
+	result, err := goflyway.Migrate(conf)
+	if err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	if result.MigrationsExecuted != 3 {
+		t.Errorf("expected 3 migrations but got %d", result.MigrationsExecuted)
+	}
+
+	applied, err := goflyway.History(context.Background(), goflyway.GoFlywayConfig{Db: db, Driver: goflyway.SQLITE3, Location: conf.Location}, goflyway.HistoryFilter{})
+	if err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	if len(applied) != 3 {
+		t.Errorf("expected 3 applied migrations but got %d", len(applied))
+	}
+}
+
+func TestSqlite3MigrateConn(t *testing.T) {
+
+	db := openSqlite3(t)
+	ctx := context.Background()
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+	defer conn.Close()
+
+	conf := getSqlite3Config(db)
+	conf.Db = conn
+
+	result, err := goflyway.Migrate(conf)
+	if err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	if result.MigrationsExecuted != 3 {
+		t.Errorf("expected 3 migrations but got %d", result.MigrationsExecuted)
+	}
+
+	// the connection of the caller is left open
+	if err = conn.PingContext(ctx); err != nil {
+		t.Errorf("expected connection to be open but got error %v", err)
+	}
+}
+
+func TestSqlite3FlywayCompatible(t *testing.T) {
+
+	db := openSqlite3(t)
+	conf := getSqlite3Config(db)
+	conf.FlywayCompatible = true
+
+	result, err := goflyway.Migrate(conf)
+	if err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	if result.MigrationsExecuted != 3 {
+		t.Fatalf("expected 3 migrations but got %d", result.MigrationsExecuted)
+	}
+
+	expected, err := goflyway.CalculateFlywayChecksum(conf.Location + "/V1__test_create_table_product.sql")
+	if err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	var checksum int32
+	var migrationType string
+	err = db.QueryRow(fmt.Sprintf(`SELECT checksum, type FROM %s WHERE version = '1'`, flywayTableName)).Scan(&checksum, &migrationType)
+	if err != nil {
+		t.Fatalf("expected history row in %s but got error %v", flywayTableName, err)
+	}
+
+	if checksum != expected || migrationType != flywayTypeSQL {
+		t.Errorf("expected checksum %d and type %s but got %d and %s", expected, flywayTypeSQL, checksum, migrationType)
+	}
+
+	result, err = goflyway.Migrate(conf)
+	if err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	if result.MigrationsExecuted != 0 {
+		t.Errorf("expected no migration but got %d", result.MigrationsExecuted)
+	}
+}
+
+func TestSqlite3FlywayCompatibleBaseline(t *testing.T) {
+
+	db := openSqlite3(t)
+	conf := getSqlite3Config(db)
+	conf.FlywayCompatible = true
+
+	b, err := os.ReadFile(conf.Location + "/V1__test_create_table_product.sql")
+	if err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	// Baseline creates the Flyway history table, its rows are replaced by those of a Flyway run
+	if err = goflyway.Baseline(conf); err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	statements := append(
+		[]string{fmt.Sprintf(`DELETE FROM %s`, flywayTableName)},
+		string(b),
+		fmt.Sprintf(`INSERT INTO %s (installed_rank, version, description, type, script, checksum, installed_by, execution_time, success)
+			VALUES (1, NULL, '<< Flyway Schema Creation >>', 'SCHEMA', 'main', NULL, 'flyway', 0, 1),
+			(2, '1', '<< Flyway Baseline >>', 'BASELINE', '<< Flyway Baseline >>', NULL, 'flyway', 0, 1),
+			(3, '0.5', 'deleted', 'SQL', 'V0_5__deleted.sql', 1, 'flyway', 0, 1),
+			(4, '0.5', 'deleted', 'DELETE', 'V0_5__deleted.sql', NULL, 'flyway', 0, 1)`, flywayTableName),
+	)
+
+	for _, s := range statements {
+		if _, err = db.Exec(s); err != nil {
+			t.Fatalf("errors happened when preparing Flyway history: %v", err)
+		}
+	}
+
+	result, err := goflyway.Migrate(conf)
+	if err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	if result.InitialSchemaVersion != "1" || result.MigrationsExecuted != 2 {
+		t.Errorf("expected 2 migrations from baseline version 1 but got %d from version %s",
+			result.MigrationsExecuted, result.InitialSchemaVersion)
+	}
+
+	var rank int
+	if err = db.QueryRow(fmt.Sprintf(`SELECT installed_rank FROM %s WHERE version = '3'`, flywayTableName)).Scan(&rank); err != nil {
+		t.Fatalf("expected history row of version 3 but got error %v", err)
+	}
+
+	if rank != 6 {
+		t.Errorf("expected installed_rank 6 after the ignored rows but got %d", rank)
+	}
+}
+
+// recordLogger Collects the logs of a Flyway
+type recordLogger struct {
+	mu   sync.Mutex
+	logs []string
+}
+
+func (l *recordLogger) Printf(format string, v ...interface{}) {
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.logs = append(l.logs, fmt.Sprintf(format, v...))
+}
+
+func TestFlywayConcurrentDatabases(t *testing.T) {
+
+	flyways := []*goflyway.Flyway{}
+	loggers := []*recordLogger{}
+
+	for i := 0; i < 4; i++ {
+
+		db, err := sql.Open("sqlite", fmt.Sprintf("file:%s_%d?mode=memory&cache=shared", t.Name(), i))
+		if err != nil {
+			t.Fatalf("errors happened when opening database: %v", err)
+		}
+		t.Cleanup(func() {
+			db.Close()
+		})
+
+		conf := getSqlite3Config(db)
+		conf.Table = fmt.Sprintf("history_%d", i)
+		conf.ShowWarningLog = true
+
+		logger := &recordLogger{}
+		conf.Logger = logger
+
+		f, err := goflyway.New(conf)
+		if err != nil {
+			t.Fatalf("expected nil but got error %v", err)
+		}
+
+		flyways = append(flyways, f)
+		loggers = append(loggers, logger)
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(flyways))
+
+	for i, f := range flyways {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = f.Migrate()
+		}()
+	}
+	wg.Wait()
+
+	for i, f := range flyways {
+
+		if errs[i] != nil {
+			t.Fatalf("expected nil but got error %v", errs[i])
+		}
+
+		infos, err := f.Info()
+		if err != nil {
+			t.Fatalf("expected nil but got error %v", err)
+		}
+
+		if len(infos) != 3 || infos[2].State != goflyway.StateSuccess {
+			t.Errorf("expected 3 applied migrations but got %v", infos)
+		}
+
+		logs := strings.Join(loggers[i].logs, "\n")
+		if !strings.Contains(logs, "successfully applied 3 migrations") {
+			t.Errorf("expected migration logs in the logger of the Flyway but got %s", logs)
+		}
+	}
+}
+
+func TestSqlite3History(t *testing.T) {
+
+	db := openSqlite3(t)
+	conf := getSqlite3Config(db)
+	ctx := context.Background()
+
+	applied, err := goflyway.History(ctx, conf, goflyway.HistoryFilter{})
+	if err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	if len(applied) != 0 {
+		t.Errorf("expected empty history before the first migration but got %v", applied)
+	}
+
+	start := time.Now().Add(-time.Minute)
+
+	if _, err = goflyway.Migrate(conf); err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	applied, err = goflyway.History(ctx, conf, goflyway.HistoryFilter{})
+	if err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	if len(applied) != 3 {
+		t.Fatalf("expected 3 applied migrations but got %v", applied)
+	}
+
+	first := applied[0]
+	if first.InstalledRank != 1 || first.Version != "1" || first.Script != "V1__test_create_table_product.sql" ||
+		first.Type != "sql" || !first.Success || len(first.Checksum) != 64 || first.InstalledOn.Before(start) {
+		t.Errorf("expected first applied migration but got %+v", first)
+	}
+
+	tests := []struct {
+		name     string
+		filter   goflyway.HistoryFilter
+		expected int
+	}{
+		{"from version", goflyway.HistoryFilter{FromVersion: "2"}, 2},
+		{"version range", goflyway.HistoryFilter{FromVersion: "2", ToVersion: "2"}, 1},
+		{"installed from", goflyway.HistoryFilter{InstalledFrom: start}, 3},
+		{"installed to", goflyway.HistoryFilter{InstalledTo: start}, 0},
+	}
+
+	for _, tt := range tests {
+
+		applied, err = goflyway.History(ctx, conf, tt.filter)
+		if err != nil {
+			t.Fatalf("%s: expected nil but got error %v", tt.name, err)
+		}
+
+		if len(applied) != tt.expected {
+			t.Errorf("%s: expected %d applied migrations but got %d", tt.name, tt.expected, len(applied))
+		}
+	}
+}
+
+func TestSqlite3HistoryLayoutNewTable(t *testing.T) {
+
+	db := openSqlite3(t)
+	conf := getSqlite3Config(db)
+
+	if _, err := goflyway.Migrate(conf); err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	var version int
+	if err := db.QueryRow(`SELECT layout_version FROM goflyway_schema_history_layout`).Scan(&version); err != nil {
+		t.Fatalf("expected layout version but got error %v", err)
+	}
+
+	if version != 2 {
+		t.Errorf("expected layout version 2 but got %d", version)
+	}
+}
@@ -0,0 +1,528 @@
+package goflyway
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// LintRule a check of Lint on the statements of the migration scripts
+type LintRule string
+
+const (
+	// LintDropTable DROP TABLE loses the data of the table
+	LintDropTable LintRule = "drop-table"
+
+	// LintDropColumn ALTER TABLE ... DROP COLUMN loses the data of the column
+	LintDropColumn LintRule = "drop-column"
+
+	// LintNotNullWithoutDefault adding a NOT NULL column without DEFAULT fails on tables with rows
+	LintNotNullWithoutDefault LintRule = "not-null-without-default"
+
+	// LintNonConcurrentIndex CREATE INDEX without CONCURRENTLY blocks the writes to the table while the index builds
+	LintNonConcurrentIndex LintRule = "non-concurrent-index"
+
+	// LintMissingWhere UPDATE or DELETE without WHERE changes every row of the table
+	LintMissingWhere LintRule = "missing-where"
+
+	// LintColumnTypeChange changing the type of a column may rewrite the table under an exclusive lock or truncate values
+	LintColumnTypeChange LintRule = "column-type-change"
+)
+
+// LintSeverity how the findings of a LintRule are reported
+type LintSeverity string
+
+const (
+	// LintOff the rule is not checked
+	LintOff LintSeverity = "off"
+
+	// LintWarning the finding is reported
+	LintWarning LintSeverity = "warning"
+
+	// LintError the finding is reported and Lint returns ErrLintFailed
+	LintError LintSeverity = "error"
+)
+
+// lintDefaults default severity and message of each rule
+var lintDefaults = map[LintRule]struct {
+	severity LintSeverity
+	message  string
+}{
+	LintDropTable:             {LintError, "DROP TABLE loses the data of the table"},
+	LintDropColumn:            {LintError, "DROP COLUMN loses the data of the column"},
+	LintNotNullWithoutDefault: {LintError, "adding a NOT NULL column without DEFAULT fails on tables with rows"},
+	LintNonConcurrentIndex:    {LintWarning, "CREATE INDEX without CONCURRENTLY blocks writes to the table while the index builds"},
+	LintMissingWhere:          {LintWarning, "UPDATE or DELETE without WHERE changes every row of the table"},
+	LintColumnTypeChange:      {LintWarning, "changing the type of a column may rewrite the table under an exclusive lock"},
+}
+
+// lintRulesDefault rules checked on dialects that are not a LintDialect
+var lintRulesDefault = []LintRule{
+	LintDropTable, LintDropColumn, LintNotNullWithoutDefault, LintMissingWhere, LintColumnTypeChange,
+}
+
+// lintAlterKeywords words following ADD, DROP or ALTER in ALTER TABLE that do not name a column
+var lintAlterKeywords = []string{
+	"CONSTRAINT", "INDEX", "KEY", "UNIQUE", "PRIMARY", "FOREIGN", "CHECK", "FULLTEXT", "SPATIAL", "PARTITION",
+	"PERIOD", "DEFAULT", "NOT", "IDENTITY", "EXPRESSION", "SET", "DROP", "ADD", "RESTART", "RESET", "OPTIONS",
+	"SYSTEM", "TRIGGER",
+}
+
+// LintFinding a statement of a migration script that breaks a LintRule
+type LintFinding struct {
+	Rule     LintRule
+	Severity LintSeverity
+	Version  string
+	Script   string
+
+	// Line of the statement in the script, starting at 1
+	Line int
+
+	// First line of the statement
+	Statement string
+
+	Message string
+}
+
+// LintResult summary of a Lint run
+type LintResult struct {
+	// Number of migration scripts checked
+	ScriptsLinted int
+
+	// Findings in script and line order
+	Findings []LintFinding
+}
+
+// Errors Returns the number of findings with LintError severity
+func (r *LintResult) Errors() int {
+
+	total := 0
+	for _, f := range r.Findings {
+		if f.Severity == LintError {
+			total++
+		}
+	}
+
+	return total
+}
+
+// lintStatement a statement of a migration script checked by Lint
+type lintStatement struct {
+	// statement without comments and string contents, on a single line
+	code string
+
+	// first line of the statement, and its last line
+	line    int
+	endLine int
+
+	// first line of the statement as written
+	first string
+
+	// rules suppressed by "-- goflyway:lint-ignore", all of them when ignoreAll
+	ignoreAll bool
+	ignored   []LintRule
+}
+
+// Lint checks the migration scripts, see Flyway.Lint
+func Lint(c GoFlywayConfig) (*LintResult, error) {
+
+	f, err := New(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return f.Lint()
+}
+
+// Lint checks the statements of the local migration scripts for changes that lose data or lock tables, without
+// connecting to the database. Each LintRule is reported with its default severity or the one of LintRules. A
+// "-- goflyway:lint-ignore" comment on the line before a statement, or on its last line, suppresses its findings,
+// optionally only for the listed rules: "-- goflyway:lint-ignore drop-column". When a finding has LintError severity
+// the result is returned with ErrLintFailed
+func (f *Flyway) Lint() (*LintResult, error) {
+	return f.runner().lint()
+}
+
+func (g *goFlywayRunner) lint() (*LintResult, error) {
+
+	severities, err := g.lintSeverities()
+	if err != nil {
+		return nil, err
+	}
+
+	mFiles, err := g.readLocalMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &LintResult{Findings: []LintFinding{}}
+
+	for _, lm := range mFiles {
+
+		b, err := os.ReadFile(fmt.Sprintf("%s/%s", g.config.Location, lm.Script))
+		if err != nil {
+			return nil, throwErrMigration(fmt.Errorf("error reading migration %s: %w", lm.Script, err))
+		}
+
+		for _, finding := range g.lintScript(replacePlaceholders(string(b), g.config.Placeholders), severities) {
+			finding.Version = lm.Version
+			finding.Script = lm.Script
+			result.Findings = append(result.Findings, finding)
+		}
+
+		result.ScriptsLinted++
+	}
+
+	if n := result.Errors(); n > 0 {
+		return result, fmt.Errorf("%w: %d findings with error severity", ErrLintFailed, n)
+	}
+
+	return result, nil
+}
+
+// lintSeverities Returns the severity of the rules of the dialect, rules turned off are left out
+func (g *goFlywayRunner) lintSeverities() (map[LintRule]LintSeverity, error) {
+
+	rules := lintRulesDefault
+	if d, ok := g.dialect.(LintDialect); ok {
+		rules = d.LintRules()
+	}
+
+	for rule, severity := range g.config.LintRules {
+
+		if _, ok := lintDefaults[rule]; !ok {
+			return nil, fmt.Errorf("%w: unknown lint rule %s", ErrInvalidConfig, rule)
+		}
+
+		switch severity {
+		case LintOff, LintWarning, LintError:
+		default:
+			return nil, fmt.Errorf("%w: unknown lint severity %s of rule %s", ErrInvalidConfig, severity, rule)
+		}
+	}
+
+	severities := map[LintRule]LintSeverity{}
+	for _, rule := range rules {
+
+		severity, ok := g.config.LintRules[rule]
+		if !ok {
+			severity = lintDefaults[rule].severity
+		}
+
+		if severity != LintOff {
+			severities[rule] = severity
+		}
+	}
+
+	return severities, nil
+}
+
+// lintScript Returns the findings of the rules of severities on script, without version nor script name
+func (g *goFlywayRunner) lintScript(script string, severities map[LintRule]LintSeverity) []LintFinding {
+
+	findings := []LintFinding{}
+
+	// tables created by the script hold no rows yet, changing them is safe
+	created := map[string]bool{}
+
+	for _, st := range g.lintStatements(script) {
+
+		if m := regexLintCreateTable.FindStringSubmatch(st.code); m != nil {
+			created[lintTableName(m[1])] = true
+			continue
+		}
+
+		for _, rule := range lintRules(st.code, created) {
+
+			severity, ok := severities[rule]
+			if !ok || st.ignoreAll || containsLintRule(st.ignored, rule) {
+				continue
+			}
+
+			findings = append(findings, LintFinding{
+				Rule:      rule,
+				Severity:  severity,
+				Line:      st.line,
+				Statement: st.first,
+				Message:   lintDefaults[rule].message,
+			})
+		}
+	}
+
+	return findings
+}
+
+// lintRules Returns the rules broken by the statement code, changes to the created tables are not reported
+func lintRules(code string, created map[string]bool) []LintRule {
+
+	rules := []LintRule{}
+
+	if m := regexLintDropTable.FindStringSubmatch(code); m != nil {
+		if !created[lintTableName(m[1])] {
+			rules = append(rules, LintDropTable)
+		}
+		return rules
+	}
+
+	if m := regexLintCreateIndex.FindStringSubmatch(code); m != nil {
+		if len(m[1]) <= 0 && !created[lintTableName(m[2])] {
+			rules = append(rules, LintNonConcurrentIndex)
+		}
+		return rules
+	}
+
+	if m := regexLintUpdate.FindStringSubmatch(code); m != nil {
+		if !regexLintWhere.MatchString(code) && !created[lintTableName(m[1])] {
+			rules = append(rules, LintMissingWhere)
+		}
+		return rules
+	}
+
+	m := regexLintAlterTable.FindStringSubmatch(code)
+	if m == nil || created[lintTableName(m[1])] {
+		return rules
+	}
+
+	for _, action := range splitTopLevel(m[2]) {
+
+		rule, ok := lintAlterAction(action)
+		if ok && !containsLintRule(rules, rule) {
+			rules = append(rules, rule)
+		}
+	}
+
+	return rules
+}
+
+// lintAlterAction Returns the rule broken by an action of ALTER TABLE, such as "DROP COLUMN name"
+func lintAlterAction(action string) (LintRule, bool) {
+
+	words := strings.Fields(strings.ToUpper(action))
+	if len(words) < 2 {
+		return "", false
+	}
+
+	// first word naming the column, after the optional COLUMN and IF [NOT] EXISTS
+	i := 1
+	for i < len(words)-1 && containsString([]string{"COLUMN", "IF", "NOT", "EXISTS"}, words[i]) {
+		i++
+	}
+	isColumn := words[1] == "COLUMN" || !containsString(lintAlterKeywords, words[i])
+
+	switch words[0] {
+	case "DROP":
+		if isColumn {
+			return LintDropColumn, true
+		}
+
+	case "ADD":
+		if isColumn && regexLintNotNull.MatchString(action) && !regexLintColumnDefault.MatchString(action) {
+			return LintNotNullWithoutDefault, true
+		}
+
+	case "ALTER":
+		m := regexLintAlterColumn.FindStringSubmatch(action)
+		if m == nil {
+			return "", false
+		}
+		next := strings.ToUpper(m[3])
+		if next == "TYPE" || (next == "SET" && len(m[4]) > 0) ||
+			(len(m[1]) > 0 && !containsString(lintAlterKeywords, next)) {
+			return LintColumnTypeChange, true
+		}
+
+	case "MODIFY", "CHANGE":
+		return LintColumnTypeChange, true
+	}
+
+	return "", false
+}
+
+// lintStatements Split script into the statements checked by Lint, with their lines and suppressions
+func (g *goFlywayRunner) lintStatements(script string) []lintStatement {
+
+	var texts []string
+	if d, ok := g.dialect.(LintDialect); ok {
+		texts = d.LintStatements(script)
+	} else {
+		texts = splitStatements(script, splitOptions{})
+	}
+
+	statements := []lintStatement{}
+	offset := 0
+
+	for _, text := range texts {
+
+		start := strings.Index(script[offset:], text)
+		if start < 0 {
+			start = 0
+		}
+		start += offset
+		offset = start + len(text)
+
+		codeStart := start + lintCodeStart(text)
+		st := lintStatement{
+			code:    lintCode(text),
+			line:    1 + strings.Count(script[:codeStart], "\n"),
+			endLine: 1 + strings.Count(script[:offset], "\n"),
+		}
+
+		first, _, _ := strings.Cut(script[codeStart:offset], "\n")
+		st.first = truncate(strings.TrimSpace(first), 80)
+
+		for _, m := range regexLintIgnore.FindAllStringSubmatchIndex(text, -1) {
+
+			line := 1 + strings.Count(script[:start+m[0]], "\n")
+
+			target := &st
+			// a directive after the end of the previous statement, on its last line, belongs to it
+			if n := len(statements); n > 0 && start+m[0] < codeStart && line == statements[n-1].endLine {
+				target = &statements[n-1]
+			}
+
+			rules := strings.FieldsFunc(text[m[2]:m[3]], func(r rune) bool { return r == ',' || r == ' ' || r == '\t' })
+			if len(rules) <= 0 {
+				target.ignoreAll = true
+			}
+			for _, rule := range rules {
+				target.ignored = append(target.ignored, LintRule(strings.ToLower(rule)))
+			}
+		}
+
+		if len(st.code) > 0 {
+			statements = append(statements, st)
+		}
+	}
+
+	return statements
+}
+
+// lintCodeStart Returns the index of the first character of text outside comments and whitespace
+func lintCodeStart(text string) int {
+
+	for i := 0; i < len(text); {
+		switch {
+		case text[i] == ' ' || text[i] == '\t' || text[i] == '\r' || text[i] == '\n':
+			i++
+		case strings.HasPrefix(text[i:], "--"):
+			end := strings.IndexByte(text[i:], '\n')
+			if end < 0 {
+				return len(text)
+			}
+			i += end
+		case strings.HasPrefix(text[i:], "/*"):
+			end := strings.Index(text[i+2:], "*/")
+			if end < 0 {
+				return len(text)
+			}
+			i += end + 4
+		default:
+			return i
+		}
+	}
+
+	return len(text)
+}
+
+// lintCode Returns statement without comments nor the contents of its string literals, with whitespace collapsed
+func lintCode(statement string) string {
+
+	var code strings.Builder
+
+	for i := 0; i < len(statement); {
+
+		c := statement[i]
+
+		switch {
+		case c == '\'':
+			end := i + 1
+			for end < len(statement) {
+				if statement[end] == '\'' {
+					if end+1 < len(statement) && statement[end+1] == '\'' {
+						end += 2
+						continue
+					}
+					break
+				}
+				end++
+			}
+			code.WriteString("''")
+			i = end + 1
+
+		case c == '$' && regexDollarQuote.MatchString(statement[i:]):
+			tag := regexDollarQuote.FindString(statement[i:])
+			end := strings.Index(statement[i+len(tag):], tag)
+			if end < 0 {
+				end = len(statement) - i - len(tag)
+			}
+			code.WriteString("''")
+			i += end + 2*len(tag)
+
+		case strings.HasPrefix(statement[i:], "--"):
+			end := strings.IndexByte(statement[i:], '\n')
+			if end < 0 {
+				end = len(statement) - i
+			}
+			code.WriteByte(' ')
+			i += end
+
+		case strings.HasPrefix(statement[i:], "/*"):
+			end := strings.Index(statement[i+2:], "*/")
+			if end < 0 {
+				end = len(statement) - i - 4
+			}
+			code.WriteByte(' ')
+			i += end + 4
+
+		default:
+			code.WriteByte(c)
+			i++
+		}
+	}
+
+	return strings.Join(strings.Fields(code.String()), " ")
+}
+
+// lintTableName Returns the table name without schema nor quotes, in lower case
+func lintTableName(name string) string {
+
+	if i := strings.LastIndexByte(name, '.'); i >= 0 {
+		name = name[i+1:]
+	}
+
+	return strings.ToLower(strings.Trim(name, "\"`[]"))
+}
+
+// splitTopLevel Split s on the commas outside parentheses
+func splitTopLevel(s string) []string {
+
+	parts := []string{}
+	depth, start := 0, 0
+
+	for i, c := range s {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, strings.TrimSpace(s[start:i]))
+				start = i + 1
+			}
+		}
+	}
+
+	return append(parts, strings.TrimSpace(s[start:]))
+}
+
+// truncate Returns s cut to n runes, with an ellipsis when cut
+func truncate(s string, n int) string {
+
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+
+	return string([]rune(s)[:n-3]) + "..."
+}
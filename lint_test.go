@@ -0,0 +1,164 @@
+package goflyway
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func lintAll(d Dialect, script string) []LintFinding {
+
+	g := &goFlywayRunner{dialect: d}
+
+	severities, _ := g.lintSeverities()
+
+	return g.lintScript(script, severities)
+}
+
+func lintFindingRules(findings []LintFinding) []LintRule {
+
+	rules := []LintRule{}
+	for _, f := range findings {
+		rules = append(rules, f.Rule)
+	}
+
+	return rules
+}
+
+func TestLintRules(t *testing.T) {
+
+	tests := []struct {
+		name      string
+		dialect   Dialect
+		statement string
+		expected  []LintRule
+	}{
+		{"drop table", &postgresDialect{}, "DROP TABLE IF EXISTS product", []LintRule{LintDropTable}},
+		{"drop column", &postgresDialect{}, "ALTER TABLE product DROP COLUMN description", []LintRule{LintDropColumn}},
+		{"drop column mysql", &mysqlDialect{}, "ALTER TABLE product DROP description", []LintRule{LintDropColumn}},
+		{"drop constraint", &postgresDialect{}, "ALTER TABLE product DROP CONSTRAINT product_pk", []LintRule{}},
+		{"drop default", &postgresDialect{}, "ALTER TABLE product ALTER COLUMN name DROP DEFAULT", []LintRule{}},
+		{"not null without default", &postgresDialect{}, "ALTER TABLE product ADD COLUMN sku TEXT NOT NULL",
+			[]LintRule{LintNotNullWithoutDefault}},
+		{"not null with default", &postgresDialect{}, "ALTER TABLE product ADD COLUMN sku TEXT NOT NULL DEFAULT 'x'",
+			[]LintRule{}},
+		{"nullable column", &sqlServerDialect{}, "ALTER TABLE product ADD sku NVARCHAR(10) NULL", []LintRule{}},
+		{"add constraint", &postgresDialect{}, "ALTER TABLE product ADD CONSTRAINT sku_not_null CHECK (sku IS NOT NULL)",
+			[]LintRule{}},
+		{"index", &postgresDialect{}, "CREATE UNIQUE INDEX idx_sku ON product (sku)", []LintRule{LintNonConcurrentIndex}},
+		{"concurrent index", &postgresDialect{}, "CREATE INDEX CONCURRENTLY idx_sku ON product (sku)", []LintRule{}},
+		{"index mysql", &mysqlDialect{}, "CREATE INDEX idx_sku ON product (sku)", []LintRule{}},
+		{"index cockroachdb", &cockroachDialect{}, "CREATE INDEX idx_sku ON product (sku)", []LintRule{}},
+		{"update without where", &postgresDialect{}, "UPDATE product SET sku = 'WHERE'", []LintRule{LintMissingWhere}},
+		{"update with where", &postgresDialect{}, "UPDATE product SET sku = 'x' WHERE sku IS NULL", []LintRule{}},
+		{"delete without where", &sqlite3Dialect{}, "DELETE FROM product", []LintRule{LintMissingWhere}},
+		{"type change", &postgresDialect{}, "ALTER TABLE product ALTER COLUMN sku TYPE VARCHAR(20)",
+			[]LintRule{LintColumnTypeChange}},
+		{"type change set data", &postgresDialect{}, "ALTER TABLE product ALTER sku SET DATA TYPE VARCHAR(20)",
+			[]LintRule{LintColumnTypeChange}},
+		{"type change mysql", &mysqlDialect{}, "ALTER TABLE product MODIFY sku VARCHAR(20)",
+			[]LintRule{LintColumnTypeChange}},
+		{"type change sqlserver", &sqlServerDialect{}, "ALTER TABLE product ALTER COLUMN sku NVARCHAR(20)",
+			[]LintRule{LintColumnTypeChange}},
+		{"set not null", &postgresDialect{}, "ALTER TABLE product ALTER COLUMN sku SET NOT NULL", []LintRule{}},
+		{"several actions", &postgresDialect{},
+			"ALTER TABLE product ADD COLUMN a INT NOT NULL, DROP COLUMN b, ALTER COLUMN c TYPE BIGINT",
+			[]LintRule{LintNotNullWithoutDefault, LintDropColumn, LintColumnTypeChange}},
+		{"comment", &postgresDialect{}, "-- DROP TABLE product\nSELECT 1", []LintRule{}},
+		{"function body", &postgresDialect{},
+			"CREATE FUNCTION f() RETURNS void AS $$ BEGIN DELETE FROM product; END $$ LANGUAGE plpgsql", []LintRule{}},
+		{"created table", &postgresDialect{},
+			"CREATE TABLE category (id INT);\nALTER TABLE category ADD COLUMN name TEXT NOT NULL;\n" +
+				"CREATE INDEX idx_category_name ON category (name);\nUPDATE category SET name = 'x'",
+			[]LintRule{}},
+	}
+
+	for _, tt := range tests {
+
+		rules := lintFindingRules(lintAll(tt.dialect, tt.statement))
+		if !reflect.DeepEqual(rules, tt.expected) {
+			t.Errorf("%s: expected %v but got %v", tt.name, tt.expected, rules)
+		}
+	}
+}
+
+func TestLintIgnore(t *testing.T) {
+
+	script := "ALTER TABLE product ADD COLUMN sku TEXT;\n" +
+		"-- goflyway:lint-ignore\n" +
+		"DROP TABLE legacy_product;\n" +
+		"ALTER TABLE product DROP COLUMN description; -- goflyway:lint-ignore drop-column\n" +
+		"-- goflyway:lint-ignore drop-column\n" +
+		"ALTER TABLE product\n" +
+		"  ALTER COLUMN name TYPE VARCHAR(200);\n" +
+		"DELETE FROM product_tmp;\n"
+
+	findings := lintAll(&postgresDialect{}, script)
+
+	if len(findings) != 2 {
+		t.Fatalf("expected 2 findings but got %+v", findings)
+	}
+
+	expected := []LintFinding{
+		{Rule: LintColumnTypeChange, Line: 6, Statement: "ALTER TABLE product"},
+		{Rule: LintMissingWhere, Line: 8, Statement: "DELETE FROM product_tmp"},
+	}
+
+	for i, e := range expected {
+		if findings[i].Rule != e.Rule || findings[i].Line != e.Line || findings[i].Statement != e.Statement {
+			t.Errorf("expected %s on line %d %q but got %s on line %d %q", e.Rule, e.Line, e.Statement,
+				findings[i].Rule, findings[i].Line, findings[i].Statement)
+		}
+	}
+}
+
+func TestLint(t *testing.T) {
+
+	conf := getFakeConfig(nil)
+	conf.Db = nil
+	conf.Driver = POSTGRES
+	conf.Location = t.TempDir()
+
+	scripts := map[string]string{
+		"V1__create_product.sql": "CREATE TABLE product (id INT);\nCREATE INDEX idx_product_id ON product (id);\n",
+		"V2__drop_sku.sql":       "UPDATE product SET sku = NULL;\n\nALTER TABLE product DROP COLUMN sku;\n",
+	}
+	for name, script := range scripts {
+		if err := os.WriteFile(conf.Location+"/"+name, []byte(script), 0644); err != nil {
+			t.Fatalf("expected nil but got error %v", err)
+		}
+	}
+
+	result, err := Lint(conf)
+	if !errors.Is(err, ErrLintFailed) {
+		t.Fatalf("expected error %v but got %v", ErrLintFailed, err)
+	}
+
+	if result.ScriptsLinted != 2 || len(result.Findings) != 2 || result.Errors() != 1 {
+		t.Fatalf("expected 2 findings in 2 scripts, 1 error, but got %+v", result)
+	}
+
+	drop := result.Findings[1]
+	if drop.Rule != LintDropColumn || drop.Severity != LintError || drop.Version != "2" ||
+		drop.Script != "V2__drop_sku.sql" || drop.Line != 3 {
+		t.Errorf("expected drop-column error on line 3 of version 2 but got %+v", drop)
+	}
+
+	conf.LintRules = map[LintRule]LintSeverity{LintDropColumn: LintWarning, LintMissingWhere: LintOff}
+
+	result, err = Lint(conf)
+	if err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	if rules := lintFindingRules(result.Findings); !reflect.DeepEqual(rules, []LintRule{LintDropColumn}) {
+		t.Errorf("expected drop-column warning only but got %v", rules)
+	}
+
+	conf.LintRules = map[LintRule]LintSeverity{"drop-schema": LintError}
+
+	if _, err = Lint(conf); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("expected error %v but got %v", ErrInvalidConfig, err)
+	}
+}
@@ -0,0 +1,122 @@
+package goflyway
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// VersionStrategy how NewMigration picks the version of the next migration
+type VersionStrategy string
+
+const (
+	// VersionIncrement increments the last part of the latest version: 3 becomes 4 and 1.2 becomes 1.3
+	VersionIncrement VersionStrategy = "increment"
+
+	// VersionTimestamp uses the current UTC time as yyyyMMddHHmmss, so branches rarely pick the same version
+	VersionTimestamp VersionStrategy = "timestamp"
+)
+
+const versionTimestampLayout = "20060102150405"
+
+// NewMigration creates an empty migration script for the next version in Location, named with SqlMigrationPrefix and
+// SqlMigrationSeparator, and returns its path. No database connection nor driver is needed
+func NewMigration(c GoFlywayConfig, description string) (string, error) {
+
+	g := &goFlywayRunner{config: c}
+
+	err := g.applyDefaultSettings()
+	if err != nil {
+		return "", err
+	}
+
+	return g.newMigration(description)
+}
+
+// NewMigration creates an empty migration script for the next version, see the NewMigration function
+func (f *Flyway) NewMigration(description string) (string, error) {
+	return f.runner().newMigration(description)
+}
+
+func (g *goFlywayRunner) newMigration(description string) (string, error) {
+
+	description = strings.Join(strings.Fields(description), "_")
+	if len(description) <= 0 || strings.ContainsAny(description, `/\`) {
+		return "", fmt.Errorf("%w: invalid description %q", ErrInvalidMigrationName, description)
+	}
+
+	mFiles, err := g.readLocalMigrations()
+	if err != nil {
+		return "", err
+	}
+
+	latest := ""
+	for _, lm := range mFiles {
+		if compareVersions(lm.Version, latest) > 0 {
+			latest = lm.Version
+		}
+	}
+
+	version, err := nextVersion(latest, g.config.VersionStrategy, time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	name := g.config.SqlMigrationPrefix + strings.ReplaceAll(version, ".", "_") + g.config.SqlMigrationSeparator +
+		description + g.config.sqlMigrationSuffix
+
+	// the script must resolve as a migration with the configured naming
+	if _, _, err = extractValuesFromScriptName(name, g.config.SqlMigrationPrefix, g.config.SqlMigrationSeparator,
+		g.config.sqlMigrationSuffix, g.versionPattern()); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidMigrationName, err)
+	}
+
+	path := filepath.Join(g.config.Location, name)
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if err != nil {
+		return "", throwErrMigration(fmt.Errorf("error creating migration: %w", err))
+	}
+
+	if err = f.Close(); err != nil {
+		return "", throwErrMigration(fmt.Errorf("error creating migration: %w", err))
+	}
+
+	g.config.Logger.Printf("created migration %s", path)
+
+	return path, nil
+}
+
+// nextVersion Returns the version following latest with the given strategy, latest is empty for the first migration
+func nextVersion(latest string, strategy VersionStrategy, now time.Time) (string, error) {
+
+	switch strategy {
+	case VersionIncrement:
+		if len(latest) <= 0 {
+			return "1", nil
+		}
+
+		parts := strings.Split(latest, ".")
+		last, err := strconv.ParseUint(parts[len(parts)-1], 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("error incrementing version %s: %w", latest, err)
+		}
+		parts[len(parts)-1] = strconv.FormatUint(last+1, 10)
+
+		return strings.Join(parts, "."), nil
+
+	case VersionTimestamp:
+		version := now.UTC().Format(versionTimestampLayout)
+		if compareVersions(version, latest) <= 0 {
+			return "", fmt.Errorf("timestamp version %s is not newer than the latest version %s", version, latest)
+		}
+
+		return version, nil
+
+	default:
+		return "", fmt.Errorf("%w: unsupported version strategy %s", ErrInvalidConfig, strategy)
+	}
+}
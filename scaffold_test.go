@@ -0,0 +1,83 @@
+package goflyway
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNextVersion(t *testing.T) {
+
+	now := time.Date(2024, 5, 2, 10, 31, 12, 0, time.UTC)
+
+	tests := []struct {
+		latest   string
+		strategy VersionStrategy
+		expected string
+	}{
+		{"", VersionIncrement, "1"},
+		{"3", VersionIncrement, "4"},
+		{"1.9", VersionIncrement, "1.10"},
+		{"", VersionTimestamp, "20240502103112"},
+		{"12", VersionTimestamp, "20240502103112"},
+	}
+
+	for _, tt := range tests {
+
+		got, err := nextVersion(tt.latest, tt.strategy, now)
+		if err != nil {
+			t.Fatalf("expected nil but got error %v", err)
+		}
+
+		if got != tt.expected {
+			t.Errorf("expected next %s version of %q to be %s but got %s", tt.strategy, tt.latest, tt.expected, got)
+		}
+	}
+
+	if _, err := nextVersion("30000101000000", VersionTimestamp, now); err == nil {
+		t.Errorf("expected error for timestamp older than the latest version but got nil")
+	}
+}
+
+func TestNewMigration(t *testing.T) {
+
+	dir := t.TempDir()
+	for _, name := range []string{"V1__create_product.sql", "V2_1__add_code.sql", "README.md"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("SELECT 1;"), 0o644); err != nil {
+			t.Fatalf("errors happened when writing script: %v", err)
+		}
+	}
+
+	c := GoFlywayConfig{Location: dir}
+
+	path, err := NewMigration(c, "add  product price")
+	if err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	if filepath.Base(path) != "V2_2__add_product_price.sql" {
+		t.Errorf("expected V2_2__add_product_price.sql but got %s", filepath.Base(path))
+	}
+
+	if b, err := os.ReadFile(path); err != nil || len(b) != 0 {
+		t.Errorf("expected empty script but got %q, %v", b, err)
+	}
+
+	c.SqlMigrationPrefix = "M"
+	c.SqlMigrationSeparator = "-"
+
+	path, err = NewMigration(c, "first")
+	if err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	if filepath.Base(path) != "M1-first.sql" {
+		t.Errorf("expected M1-first.sql but got %s", filepath.Base(path))
+	}
+
+	if _, err = NewMigration(c, "  "); !errors.Is(err, ErrInvalidMigrationName) {
+		t.Errorf("expected ErrInvalidMigrationName but got %v", err)
+	}
+}
@@ -1,9 +1,78 @@
 package goflyway
 
+// Schema history layout, shared by the dialects
+
+const selectLayoutVersion = `SELECT layout_version FROM [tableName]`
+
+const insertLayoutVersion = `INSERT INTO [tableName] (layout_version) VALUES ([layoutVersion])`
+
+const updateLayoutVersion = `UPDATE [tableName] SET layout_version = [layoutVersion]`
+
+const historyTableExists = `SELECT 1 FROM [tableName] WHERE 1 = 0`
+
 // Postgres
 
+const createSchemaPostgres = `CREATE SCHEMA IF NOT EXISTS [schemaName]`
+
+const searchPathPostgres = `SET LOCAL search_path TO [schemaNames]`
+
+const currentSearchPathPostgres = `SELECT current_setting('search_path')`
+
+const restoreSearchPathPostgres = `SELECT set_config('search_path', $1, true)`
+
+const currentSchemaPostgres = `SELECT current_schema()`
+
+const currentUserPostgres = `SELECT current_user`
+
+const lockTimeoutPostgres = `SET lock_timeout = %d`
+
+const statementTimeoutPostgres = `SET statement_timeout = %d`
+
+const databaseExistsPostgres = `SELECT COUNT(*) FROM pg_database WHERE datname = $1`
+
+const createDatabasePostgres = `CREATE DATABASE [databaseName]`
+
+const createDatabaseCockroachDb = `CREATE DATABASE IF NOT EXISTS [databaseName]`
+
+const historyTableExistsPostgres = `SELECT COUNT(*) FROM information_schema.tables
+	WHERE table_schema = COALESCE(NULLIF($1, ''), current_schema()) AND table_name = $2`
+
+const lockPostgres = `SELECT pg_advisory_lock($1)`
+
+const unlockPostgres = `SELECT pg_advisory_unlock($1)`
+
+// cleanPostgres generates the commands that drop the objects of schema $1
+const cleanPostgres = `
+	SELECT stmt FROM (
+		SELECT 1 AS ord, 'DROP MATERIALIZED VIEW IF EXISTS ' || quote_ident(schemaname) || '.' || quote_ident(matviewname) || ' CASCADE' AS stmt
+		FROM pg_matviews WHERE schemaname = $1
+		UNION ALL
+		SELECT 2, 'DROP VIEW IF EXISTS ' || quote_ident(schemaname) || '.' || quote_ident(viewname) || ' CASCADE'
+		FROM pg_views WHERE schemaname = $1
+		UNION ALL
+		SELECT 3, 'DROP TABLE IF EXISTS ' || quote_ident(schemaname) || '.' || quote_ident(tablename) || ' CASCADE'
+		FROM pg_tables WHERE schemaname = $1
+		UNION ALL
+		SELECT 4, 'DROP SEQUENCE IF EXISTS ' || quote_ident(sequence_schema) || '.' || quote_ident(sequence_name) || ' CASCADE'
+		FROM information_schema.sequences WHERE sequence_schema = $1
+		UNION ALL
+		SELECT 5, 'DROP FUNCTION IF EXISTS ' || p.oid::regprocedure || ' CASCADE'
+		FROM pg_proc p JOIN pg_namespace n ON n.oid = p.pronamespace
+		WHERE n.nspname = $1 AND p.prokind = 'f'
+		  AND NOT EXISTS (SELECT 1 FROM pg_depend d WHERE d.objid = p.oid AND d.deptype = 'e')
+		UNION ALL
+		SELECT 6, 'DROP DOMAIN IF EXISTS ' || quote_ident(n.nspname) || '.' || quote_ident(t.typname) || ' CASCADE'
+		FROM pg_type t JOIN pg_namespace n ON n.oid = t.typnamespace
+		WHERE n.nspname = $1 AND t.typtype = 'd'
+		UNION ALL
+		SELECT 7, 'DROP TYPE IF EXISTS ' || quote_ident(n.nspname) || '.' || quote_ident(t.typname) || ' CASCADE'
+		FROM pg_type t JOIN pg_namespace n ON n.oid = t.typnamespace
+		WHERE n.nspname = $1 AND t.typtype = 'e'
+	) s ORDER BY ord
+`
+
 const createTablePostgres = `
-	CREATE TABLE IF NOT EXISTS "[tableName]" (
+	CREATE TABLE IF NOT EXISTS [tableName] (
 		installed_rank BIGINT NOT NULL,
 		"version" VARCHAR(255),
 		description VARCHAR(255),
@@ -15,25 +84,87 @@ const createTablePostgres = `
 		execution_time BIGINT,
 		success BOOLEAN,
 
-		CONSTRAINT pk_goflyway_sch_hist PRIMARY KEY (installed_rank)
+		CONSTRAINT [primaryKeyName] PRIMARY KEY (installed_rank)
 	)
 `
 
+// selectTablePostgres returns installed_on as timestamptz, stored values are in the session time zone
 const selectTablePostgres = `
 	SELECT installed_rank, "version", description, "type", "script", 
-	  	   checksum, installed_by, installed_on, execution_time, success
-	FROM "[tableName]" ORDER BY "version"
+	  	   checksum, installed_by, installed_on AT TIME ZONE current_setting('TimeZone') AS installed_on, execution_time, success
+	FROM [tableName] ORDER BY "version"
 `
 
 const insertPostgres = `
-	INSERT INTO "[tableName]"
+	INSERT INTO [tableName]
 	(installed_rank, "version", description, "type", script, checksum, installed_by, installed_on, execution_time, success)
-	VALUES($1, $2, $3, $4, $5, $6, current_user, current_timestamp, $7, true);
+	VALUES($1, $2, $3, $4, $5, $6, $7, current_timestamp, $8, true);
+`
+
+// createLayoutTablePostgres holds the layout version of the schema history table
+const createLayoutTablePostgres = `CREATE TABLE IF NOT EXISTS [tableName] (layout_version INT NOT NULL)`
+
+// renamePrimaryKeyPostgres upgrades to layout 2, primary key names are unique in a schema
+const renamePrimaryKeyPostgres = `ALTER TABLE [tableName] RENAME CONSTRAINT pk_goflyway_sch_hist TO [primaryKeyName]`
+
+const updatePostgres = `UPDATE [tableName] SET checksum = $1, description = $2 WHERE installed_rank = $3`
+
+const deletePostgres = `DELETE FROM [tableName] WHERE installed_rank = $1`
+
+// createTableFlywayPostgres is the schema history table layout of Java Flyway
+const createTableFlywayPostgres = `
+	CREATE TABLE IF NOT EXISTS [tableName] (
+		"installed_rank" INT NOT NULL,
+		"version" VARCHAR(50),
+		"description" VARCHAR(200) NOT NULL,
+		"type" VARCHAR(20) NOT NULL,
+		"script" VARCHAR(1000) NOT NULL,
+		"checksum" INTEGER,
+		"installed_by" VARCHAR(100) NOT NULL,
+		"installed_on" TIMESTAMP NOT NULL DEFAULT now(),
+		"execution_time" INTEGER NOT NULL,
+		"success" BOOLEAN NOT NULL,
+
+		CONSTRAINT [primaryKeyName] PRIMARY KEY ("installed_rank")
+	);
+	CREATE INDEX IF NOT EXISTS [successIndexName] ON [tableName] ("success");
 `
 
 // MySQL
 
-const createTableMysql = "CREATE TABLE IF NOT EXISTS `[tableName]` (" +
+const createSchemaMysql = "CREATE SCHEMA IF NOT EXISTS [schemaName]"
+
+const currentSchemaMysql = "SELECT DATABASE()"
+
+const lockTimeoutMysql = "SET SESSION lock_wait_timeout = %d"
+
+const innodbLockTimeoutMysql = "SET SESSION innodb_lock_wait_timeout = %d"
+
+const statementTimeoutMysql = "SET SESSION max_execution_time = %d"
+
+const databaseExistsMysql = "SELECT COUNT(*) FROM information_schema.schemata WHERE schema_name = ?"
+
+const createDatabaseMysql = "CREATE DATABASE IF NOT EXISTS [databaseName]"
+
+const currentUserMysql = "SELECT CURRENT_USER()"
+
+const lockMysql = "SELECT GET_LOCK(?, -1)"
+
+const unlockMysql = "SELECT RELEASE_LOCK(?)"
+
+// cleanMysql generates the commands that drop the objects of the schema bound twice
+const cleanMysql = "SELECT stmt FROM (" +
+	" SELECT CASE WHEN table_type = 'VIEW' THEN 1 ELSE 2 END AS ord," +
+	"  CONCAT(CASE WHEN table_type = 'VIEW' THEN 'DROP VIEW IF EXISTS ' ELSE 'DROP TABLE IF EXISTS ' END," +
+	"   '`', REPLACE(table_schema, '`', '``'), '`.`', REPLACE(table_name, '`', '``'), '`') AS stmt" +
+	" FROM information_schema.tables WHERE table_schema = ?" +
+	" UNION ALL" +
+	" SELECT 3, CONCAT('DROP ', routine_type, ' IF EXISTS '," +
+	"   '`', REPLACE(routine_schema, '`', '``'), '`.`', REPLACE(routine_name, '`', '``'), '`')" +
+	" FROM information_schema.routines WHERE routine_schema = ?" +
+	" ) s ORDER BY ord"
+
+const createTableMysql = "CREATE TABLE IF NOT EXISTS [tableName] (" +
 	" installed_rank BIGINT NOT NULL, " +
 	" `version` VARCHAR(255), " +
 	" description VARCHAR(255), " +
@@ -44,22 +175,179 @@ const createTableMysql = "CREATE TABLE IF NOT EXISTS `[tableName]` (" +
 	" installed_on TIMESTAMP, " +
 	" execution_time BIGINT, " +
 	" success BOOLEAN, " +
-	"	CONSTRAINT pk_goflyway_sch_hist PRIMARY KEY (installed_rank) " +
+	"	CONSTRAINT [primaryKeyName] PRIMARY KEY (installed_rank) " +
 	" ) ENGINE=InnoDB DEFAULT CHARSET=utf8 COLLATE utf8_bin; "
 
+// selectTableMysql returns installed_on as seconds since epoch, which does not depend on the session time zone
 const selectTableMysql = "SELECT installed_rank, `version`, description, `type`, `script`," +
-	" checksum, installed_by, installed_on, execution_time, success" +
-	" FROM `[tableName]` ORDER BY version"
+	" checksum, installed_by, UNIX_TIMESTAMP(installed_on) AS installed_on, execution_time, success" +
+	" FROM [tableName] ORDER BY version"
+
+const insertMysql = "INSERT INTO [tableName] " +
+	"(installed_rank, `version`, description, `type`, `script`, checksum, installed_by, installed_on, execution_time, success)" +
+	" VALUES(?, ?, ?, ?, ?, ?, ?, current_timestamp, ?, true)"
+
+const createLayoutTableMysql = "CREATE TABLE IF NOT EXISTS [tableName] (layout_version INT NOT NULL)"
+
+const updateMysql = "UPDATE [tableName] SET checksum = ?, description = ? WHERE installed_rank = ?"
+
+const deleteMysql = "DELETE FROM [tableName] WHERE installed_rank = ?"
+
+// CockroachDB
+
+const createTableCockroachDb = `
+	CREATE TABLE IF NOT EXISTS [tableName] (
+		installed_rank INT8 NOT NULL,
+		"version" STRING,
+		description STRING,
+		"type" STRING,
+		"script" STRING,
+		checksum STRING,
+		installed_by STRING,
+		installed_on TIMESTAMP,
+		execution_time INT8,
+		success BOOL,
+
+		CONSTRAINT [primaryKeyName] PRIMARY KEY (installed_rank)
+	)
+`
+
+const createLockTableCockroachDb = `
+	CREATE TABLE IF NOT EXISTS [tableName] (
+		id INT8 NOT NULL PRIMARY KEY,
+		locked_by STRING NOT NULL,
+		locked_on TIMESTAMP NOT NULL DEFAULT current_timestamp()
+	)
+`
+
+const lockCockroachDb = `INSERT INTO [tableName] (id, locked_by) VALUES (1, $1) ON CONFLICT (id) DO NOTHING`
+
+// takeOverLockCockroachDb takes over the lock row acquired more than $2 milliseconds ago by a run that crashed
+const takeOverLockCockroachDb = `
+	UPDATE [tableName] SET locked_by = $1, locked_on = current_timestamp()
+	WHERE id = 1 AND locked_on < current_timestamp() - $2::INT8 * INTERVAL '1 millisecond'
+`
+
+const unlockCockroachDb = `DELETE FROM [tableName] WHERE id = 1 AND locked_by = $1`
+
+// cleanCockroachDb generates the commands that drop the objects of schema $1
+const cleanCockroachDb = `
+	SELECT stmt FROM (
+		SELECT CASE WHEN table_type = 'VIEW' THEN 1 ELSE 2 END AS ord,
+			CASE WHEN table_type = 'VIEW' THEN 'DROP VIEW IF EXISTS ' ELSE 'DROP TABLE IF EXISTS ' END
+			|| quote_ident(table_schema) || '.' || quote_ident(table_name) || ' CASCADE' AS stmt
+		FROM information_schema.tables WHERE table_schema = $1 AND table_type IN ('BASE TABLE', 'VIEW')
+		UNION ALL
+		SELECT 3, 'DROP SEQUENCE IF EXISTS ' || quote_ident(sequence_schema) || '.' || quote_ident(sequence_name) || ' CASCADE'
+		FROM information_schema.sequences WHERE sequence_schema = $1
+	) s ORDER BY ord
+`
 
-const insertMysql = "INSERT INTO `[tableName]` " +
+// createTableFlywayMysql is the schema history table layout of Java Flyway
+const createTableFlywayMysql = "CREATE TABLE IF NOT EXISTS [tableName] (" +
+	" `installed_rank` INT NOT NULL, " +
+	" `version` VARCHAR(50), " +
+	" `description` VARCHAR(200) NOT NULL, " +
+	" `type` VARCHAR(20) NOT NULL, " +
+	" `script` VARCHAR(1000) NOT NULL, " +
+	" `checksum` INT, " +
+	" `installed_by` VARCHAR(100) NOT NULL, " +
+	" `installed_on` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP, " +
+	" `execution_time` INT NOT NULL, " +
+	" `success` BOOL NOT NULL, " +
+	"	CONSTRAINT [primaryKeyName] PRIMARY KEY (`installed_rank`), " +
+	"	INDEX [successIndexName] (`success`) " +
+	" ) ENGINE=InnoDB"
+
+// MariaDB
+
+const versionMysql = "SELECT VERSION()"
+
+const statementTimeoutMariaDb = "SET SESSION max_statement_time = %g"
+
+const currentUserMariaDb = "SELECT SUBSTRING_INDEX(CURRENT_USER(), '@', 1)"
+
+const historyTableExistsMariaDb = "SELECT COUNT(*) FROM information_schema.tables" +
+	" WHERE table_schema = COALESCE(NULLIF(?, ''), DATABASE()) AND table_name = ?"
+
+const createTableMariaDb = "CREATE TABLE IF NOT EXISTS [tableName] (" +
+	" installed_rank BIGINT NOT NULL, " +
+	" `version` VARCHAR(255), " +
+	" description VARCHAR(255), " +
+	" `type` VARCHAR(50), " +
+	" `script` VARCHAR(255), " +
+	" checksum VARCHAR(255), " +
+	" installed_by VARCHAR(255), " +
+	" installed_on TIMESTAMP NULL DEFAULT NULL, " +
+	" execution_time BIGINT, " +
+	" success BOOLEAN, " +
+	"	CONSTRAINT [primaryKeyName] PRIMARY KEY (installed_rank) " +
+	" ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE utf8mb4_bin"
+
+const insertMariaDb = "INSERT INTO [tableName] " +
 	"(installed_rank, `version`, description, `type`, `script`, checksum, installed_by, installed_on, execution_time, success)" +
-	" VALUES(?, ?, ?, ?, ?, ?, current_user, current_timestamp, ?, true)"
+	" VALUES(?, ?, ?, ?, ?, ?, ?, current_timestamp, ?, true)"
+
+// cleanSequencesMariaDb generates the commands that drop the sequences of the schema
+const cleanSequencesMariaDb = "SELECT CONCAT('DROP SEQUENCE IF EXISTS '," +
+	" '`', REPLACE(sequence_schema, '`', '``'), '`.`', REPLACE(sequence_name, '`', '``'), '`')" +
+	" FROM information_schema.sequences WHERE sequence_schema = ?"
 
 // Microsoft Sql Server
 
+const lockTimeoutMsSqlServer = `SET LOCK_TIMEOUT %d`
+
+const databaseExistsMsSqlServer = `SELECT COUNT(*) FROM sys.databases WHERE name = @database`
+
+const createDatabaseMsSqlServer = `CREATE DATABASE [databaseName]`
+
+const createSchemaMsSqlServer = `
+	IF SCHEMA_ID('[schemaNameLiteral]') IS NULL
+		EXEC('CREATE SCHEMA ' + QUOTENAME('[schemaNameLiteral]'))
+`
+
+const currentSchemaMsSqlServer = `SELECT SCHEMA_NAME()`
+
+const currentUserMsSqlServer = `SELECT CURRENT_USER`
+
+const lockMsSqlServer = `
+	DECLARE @result INT;
+	EXEC @result = sp_getapplock @Resource = @resource, @LockMode = 'Exclusive', @LockOwner = 'Session', @LockTimeout = -1;
+	SELECT @result;
+`
+
+const unlockMsSqlServer = `EXEC sp_releaseapplock @Resource = @resource, @LockOwner = 'Session'`
+
+// cleanMsSqlServer generates the commands that drop the objects of schema @schema
+const cleanMsSqlServer = `
+	SELECT stmt FROM (
+		SELECT 1 AS ord, 'ALTER TABLE ' + QUOTENAME(s.name) + '.' + QUOTENAME(t.name) + ' DROP CONSTRAINT ' + QUOTENAME(f.name) AS stmt
+		FROM sys.foreign_keys f
+		JOIN sys.tables t ON t.object_id = f.parent_object_id
+		JOIN sys.schemas s ON s.schema_id = t.schema_id
+		WHERE s.name = @schema
+		UNION ALL
+		SELECT 2, 'DROP VIEW ' + QUOTENAME(s.name) + '.' + QUOTENAME(v.name)
+		FROM sys.views v JOIN sys.schemas s ON s.schema_id = v.schema_id WHERE s.name = @schema
+		UNION ALL
+		SELECT 3, 'DROP TABLE ' + QUOTENAME(s.name) + '.' + QUOTENAME(t.name)
+		FROM sys.tables t JOIN sys.schemas s ON s.schema_id = t.schema_id WHERE s.name = @schema
+		UNION ALL
+		SELECT 4, 'DROP PROCEDURE ' + QUOTENAME(s.name) + '.' + QUOTENAME(p.name)
+		FROM sys.procedures p JOIN sys.schemas s ON s.schema_id = p.schema_id WHERE s.name = @schema
+		UNION ALL
+		SELECT 5, 'DROP FUNCTION ' + QUOTENAME(s.name) + '.' + QUOTENAME(o.name)
+		FROM sys.objects o JOIN sys.schemas s ON s.schema_id = o.schema_id
+		WHERE s.name = @schema AND o.type IN ('FN', 'IF', 'TF')
+		UNION ALL
+		SELECT 6, 'DROP SEQUENCE ' + QUOTENAME(s.name) + '.' + QUOTENAME(q.name)
+		FROM sys.sequences q JOIN sys.schemas s ON s.schema_id = q.schema_id WHERE s.name = @schema
+	) s ORDER BY ord
+`
+
 const createTableMsSqlServer = `
-	IF NOT EXISTS (SELECT * FROM INFORMATION_SCHEMA.COLUMNS WHERE [TABLE_NAME] = '[tableName]')
-		CREATE TABLE "[tableName]" (
+	IF OBJECT_ID('[tableNameLiteral]', 'U') IS NULL
+		CREATE TABLE [tableName] (
 			installed_rank BIGINT NOT NULL,
 			"version" VARCHAR(255),
 			description VARCHAR(255),
@@ -71,25 +359,70 @@ const createTableMsSqlServer = `
 			execution_time BIGINT,
 			success BIT,
 
-			CONSTRAINT pk_goflyway_sch_hist PRIMARY KEY (installed_rank)
+			CONSTRAINT [primaryKeyName] PRIMARY KEY (installed_rank)
 		)
 `
 
+// selectTableMsSqlServer returns installed_on as datetimeoffset using the server offset, current_timestamp is server local time
 const selectTableMsSqlServer = `
 	SELECT installed_rank, "version", description, "type", "script", 
-	  	   checksum, installed_by, installed_on, execution_time, success
-	FROM "[tableName]" ORDER BY "version"
+	  	   checksum, installed_by, TODATETIMEOFFSET(installed_on, DATEPART(TZOFFSET, SYSDATETIMEOFFSET())) AS installed_on, execution_time, success
+	FROM [tableName] ORDER BY "version"
 `
 const insertMsSqlServer = `
-	INSERT INTO "[tableName]"
+	INSERT INTO [tableName]
 	(installed_rank, "version", description, "type", script, checksum, installed_by, installed_on, execution_time, success)
-	VALUES(@installed_rank, @version, @description, @type, @script, @checksum, current_user, current_timestamp, @execution_time, 1)
+	VALUES(@installed_rank, @version, @description, @type, @script, @checksum, @installed_by, current_timestamp, @execution_time, 1)
+`
+
+const createLayoutTableMsSqlServer = `
+	IF OBJECT_ID('[tableNameLiteral]', 'U') IS NULL
+		CREATE TABLE [tableName] (layout_version INT NOT NULL)
+`
+
+// renamePrimaryKeyMsSqlServer upgrades to layout 2, primary key names are unique in a schema. [tableNameLiteral] is the
+// qualified legacy name and [primaryKeyName] the unquoted new name, sp_rename does not expect it quoted
+const renamePrimaryKeyMsSqlServer = `EXEC sp_rename '[tableNameLiteral]', '[primaryKeyName]', 'OBJECT'`
+
+const updateMsSqlServer = `UPDATE [tableName] SET checksum = @checksum, description = @description WHERE installed_rank = @installed_rank`
+
+const deleteMsSqlServer = `DELETE FROM [tableName] WHERE installed_rank = @installed_rank`
+
+// createTableFlywayMsSqlServer is the schema history table layout of Java Flyway
+const createTableFlywayMsSqlServer = `
+	IF OBJECT_ID('[tableNameLiteral]', 'U') IS NULL
+	BEGIN
+		CREATE TABLE [tableName] (
+			[installed_rank] INT NOT NULL,
+			[version] NVARCHAR(50),
+			[description] NVARCHAR(200),
+			[type] NVARCHAR(20) NOT NULL,
+			[script] NVARCHAR(1000) NOT NULL,
+			[checksum] INT,
+			[installed_by] NVARCHAR(100) NOT NULL,
+			[installed_on] DATETIME NOT NULL DEFAULT GETDATE(),
+			[execution_time] INT NOT NULL,
+			[success] BIT NOT NULL,
+
+			CONSTRAINT [primaryKeyName] PRIMARY KEY ([installed_rank])
+		);
+		CREATE INDEX [successIndexName] ON [tableName] ([success]);
+	END
 `
 
 // Sqlite3
 
+const busyTimeoutSqlite3 = `PRAGMA busy_timeout = %d`
+
+// cleanSqlite3 lists the objects of schema, views first
+const cleanSqlite3 = `
+	SELECT type, name FROM [schemaName].sqlite_master
+	WHERE type IN ('table', 'view') AND name NOT LIKE 'sqlite_%'
+	ORDER BY type DESC
+`
+
 const createTableSqlite3 = `
-	CREATE TABLE IF NOT EXISTS "[tableName]" (
+	CREATE TABLE IF NOT EXISTS [tableName] (
 		installed_rank BIGINT NOT NULL,
 		"version" VARCHAR(255),
 		description VARCHAR(255),
@@ -101,18 +434,41 @@ const createTableSqlite3 = `
 		execution_time BIGINT,
 		success BOOLEAN,
 
-		CONSTRAINT pk_goflyway_sch_hist PRIMARY KEY (installed_rank)
+		CONSTRAINT [primaryKeyName] PRIMARY KEY (installed_rank)
 	)
 `
 
 const selectTableSqlite3 = `
 	SELECT installed_rank, "version", description, "type", "script", 
 	  	   checksum, installed_by, installed_on, execution_time, success
-	FROM "[tableName]" ORDER BY "version"
+	FROM [tableName] ORDER BY "version"
 `
 
 const insertSqlite3 = `
-	INSERT INTO "[tableName]"
+	INSERT INTO [tableName]
 	(installed_rank, "version", description, "type", script, checksum, installed_by, installed_on, execution_time, success)
-	VALUES(?, ?, ?, ?, ?, ?, "anonymous", current_timestamp, ?, true);
+	VALUES(?, ?, ?, ?, ?, ?, ?, current_timestamp, ?, true);
+`
+
+const createLayoutTableSqlite3 = `CREATE TABLE IF NOT EXISTS [tableName] (layout_version INT NOT NULL)`
+
+const updateSqlite3 = `UPDATE [tableName] SET checksum = ?, description = ? WHERE installed_rank = ?`
+
+const deleteSqlite3 = `DELETE FROM [tableName] WHERE installed_rank = ?`
+
+// createTableFlywaySqlite3 is the schema history table layout of Java Flyway
+const createTableFlywaySqlite3 = `
+	CREATE TABLE IF NOT EXISTS [tableName] (
+		"installed_rank" INT NOT NULL PRIMARY KEY,
+		"version" VARCHAR(50),
+		"description" VARCHAR(200) NOT NULL,
+		"type" VARCHAR(20) NOT NULL,
+		"script" VARCHAR(1000) NOT NULL,
+		"checksum" INT,
+		"installed_by" VARCHAR(100) NOT NULL,
+		"installed_on" TEXT NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f','now')),
+		"execution_time" INT NOT NULL,
+		"success" BOOLEAN NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS [successIndexName] ON [tableName] ("success");
 `
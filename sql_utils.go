@@ -1,9 +1,14 @@
 package goflyway
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"math"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -15,76 +20,24 @@ type historyModelDb struct {
 	Script        *string
 	Checksum      *string
 	InstalledBy   *string
-	InstalledOn   *string
+	InstalledOn   nullTimestamp
 	ExecutionTime *int
 	Success       *bool
 }
 
-var fail = func(err error) (int64, error) {
-	return 0, fmt.Errorf("error inserting migration history: %v", err)
-}
-
-func getCreateTableCommand(driver driver, tableName string) string {
-	var createCommand string
-
-	switch driver {
-	case POSTGRES:
-		createCommand = createTablePostgres
-	case MYSQL:
-		createCommand = createTableMysql
-	case MSSQLSERVER:
-		createCommand = createTableMsSqlServer
-	case SQLITE3:
-		createCommand = createTableSqlite3
-	}
-
-	return regexTableName.ReplaceAllString(createCommand, tableName)
-}
+// scriptRetriesIntervalDefault is the longest wait between the attempts of a script when ScriptRetriesInterval is not set
+const scriptRetriesIntervalDefault = 10 * time.Second
 
-func getSelectTableCommand(driver driver, tableName string) string {
-	var selectCommand string
+// scriptFirstRetryInterval is the wait before the first retry of a script, doubled after each failed attempt
+const scriptFirstRetryInterval = 100 * time.Millisecond
 
-	switch driver {
-	case POSTGRES:
-		selectCommand = selectTablePostgres
-	case MYSQL:
-		selectCommand = selectTableMysql
-	case MSSQLSERVER:
-		selectCommand = selectTableMsSqlServer
-	case SQLITE3:
-		selectCommand = selectTableSqlite3
-	}
-	return regexTableName.ReplaceAllString(selectCommand, tableName)
-}
-
-func parseInsertMigration(driver driver, tableName string) string {
-	var insertCommand string
-
-	switch driver {
-	case POSTGRES:
-		insertCommand = insertPostgres
-	case MYSQL:
-		insertCommand = insertMysql
-	case MSSQLSERVER:
-		insertCommand = insertMsSqlServer
-	case SQLITE3:
-		insertCommand = insertSqlite3
-	}
-	return regexTableName.ReplaceAllString(insertCommand, tableName)
-}
-
-func validateDriver(s string) error {
-	switch s {
-	case string(POSTGRES), string(MYSQL), string(MSSQLSERVER), string(SQLITE3):
-		return nil
-	default:
-		return ErrUnsupportedDatabaseDriver
-	}
+var fail = func(err error) (int64, error) {
+	return 0, fmt.Errorf("error inserting migration history: %w", err)
 }
 
 // selectMigrationHistory Query migration table
-func selectMigrationHistory(db *sql.DB, query string) ([]historyModel, error) {
-	rows, err := db.Query(query)
+func selectMigrationHistory(ctx context.Context, db Executor, query string, g *goFlywayRunner) ([]historyModel, error) {
+	rows, err := db.QueryContext(ctx, query)
 
 	if err != nil {
 		return nil, err
@@ -139,10 +92,13 @@ func selectMigrationHistory(db *sql.DB, query string) ([]historyModel, error) {
 			m.InstalledBy = *v.InstalledBy
 		}
 
-		if v.InstalledOn != nil {
-			t, err := time.Parse("2006-01-02T15:04:05Z", *v.InstalledOn)
+		if v.InstalledOn.Valid {
+			t := v.InstalledOn.Time
+			m.InstalledOn = &t
+		} else if len(v.InstalledOn.raw) > 0 {
+			t, err := parseInstalledOn(v.InstalledOn.raw, timestampLayouts(g.dialect))
 			if err != nil {
-				printWarningLog(fmt.Sprintf("error parse installed_on: %v", err))
+				g.warn(fmt.Sprintf("error parse installed_on: %v", err))
 			} else {
 				m.InstalledOn = &t
 			}
@@ -161,36 +117,142 @@ func selectMigrationHistory(db *sql.DB, query string) ([]historyModel, error) {
 	return result, nil
 }
 
-func executeMigration(db *sql.DB, insertQuery string, history historyModel, g *goFlywayRunner) (*historyModel, error) {
+// defaultTimestampLayouts Layouts tried for installed_on values returned as text by dialects that do not implement TimestampDialect
+var defaultTimestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999",
+}
+
+// nullTimestamp Scans installed_on like sql.NullTime, also accepting text, kept to be parsed with the
+// dialect layouts, and seconds since epoch
+type nullTimestamp struct {
+	Time  time.Time
+	Valid bool
+	raw   string
+}
+
+func (n *nullTimestamp) Scan(value interface{}) error {
+
+	*n = nullTimestamp{}
+
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case time.Time:
+		n.Time, n.Valid = v, true
+	case int64:
+		n.Time, n.Valid = time.Unix(v, 0).UTC(), true
+	case float64:
+		sec, frac := math.Modf(v)
+		n.Time, n.Valid = time.Unix(int64(sec), int64(frac*1e9)).UTC(), true
+	case []byte:
+		n.raw = string(v)
+	case string:
+		n.raw = v
+	default:
+		return fmt.Errorf("unsupported installed_on value of type %T", value)
+	}
+
+	return nil
+}
+
+// parseInstalledOn Parse installed_on text with the given layouts, values without zone are UTC.
+// Numeric text is read as seconds since epoch
+func parseInstalledOn(value string, layouts []string) (time.Time, error) {
 
-	startExec := time.Now().UnixMilli()
+	value = strings.TrimSpace(value)
+
+	var err error
+	for _, layout := range layouts {
+
+		var t time.Time
+		t, err = time.Parse(layout, value)
+		if err == nil {
+			return t, nil
+		}
+	}
+
+	if seconds, errEpoch := strconv.ParseFloat(value, 64); errEpoch == nil {
+		sec, frac := math.Modf(seconds)
+		return time.Unix(int64(sec), int64(frac*1e9)).UTC(), nil
+	}
+
+	if err == nil {
+		err = fmt.Errorf("no layout to parse %q", value)
+	}
+
+	return time.Time{}, err
+}
+
+// timestampLayouts Returns the layouts used to parse installed_on text for the dialect
+func timestampLayouts(d Dialect) []string {
+
+	if t, ok := d.(TimestampDialect); ok {
+		return t.TimestampLayouts()
+	}
+
+	return defaultTimestampLayouts
+}
+
+func executeMigration(ctx context.Context, db Executor, insertQuery string, history historyModel, g *goFlywayRunner) (*MigrateOutput, error) {
+
+	startExec := time.Now()
+
+	maxInterval := g.config.ScriptRetriesInterval
+	if maxInterval <= 0 {
+		maxInterval = scriptRetriesIntervalDefault
+	}
+
+	interval := minDuration(scriptFirstRetryInterval, maxInterval)
 
 	// execute
-	_, err := executeScript(db, history, g)
+	rowsAffected, partial, err := executeScript(ctx, db, history, g)
+	for attempt := 1; err != nil && !partial && g.tx == nil; attempt++ {
+
+		retries := g.scriptRetries(err)
+		if attempt > retries {
+			break
+		}
+
+		g.config.Logger.Printf("migration %s failed with a transient error, retrying in %s (attempt %d of %d): %v",
+			history.Script, interval, attempt, retries, err)
+
+		time.Sleep(interval)
+		interval = minDuration(interval*2, maxInterval)
+
+		rowsAffected, partial, err = executeScript(ctx, db, history, g)
+	}
 	if err != nil {
 		return nil, err
 	}
-	endExec := time.Now().UnixMilli()
+	elapsed := time.Since(startExec)
 
-	total := int(endExec - startExec)
-	history.ExecutionTime = total
+	history.ExecutionTime = int(elapsed.Milliseconds())
 
-	_, err = insertMigration(db, insertQuery, history, g)
+	_, err = insertMigration(ctx, db, insertQuery, history, g)
 	if err != nil {
 		return nil, err
 	}
 
-	return nil, nil
+	return &MigrateOutput{
+		Version:       history.Version,
+		Description:   history.Description,
+		Type:          history.Type,
+		Script:        history.Script,
+		ExecutionTime: elapsed,
+		RowsAffected:  rowsAffected,
+	}, nil
 }
 
-func insertMigration(db *sql.DB, insertQuery string, history historyModel, g *goFlywayRunner) (int64, error) {
-	tx, err := db.Begin()
+func insertMigration(ctx context.Context, db Executor, insertQuery string, history historyModel, g *goFlywayRunner) (int64, error) {
+	tx, err := g.beginTx(ctx, db)
 	if err != nil {
 		return fail(err)
 	}
 	defer tx.Rollback()
 
-	r1, err := insertExecutor(tx, insertQuery, history, g)
+	r1, err := insertExecutor(tx.Tx, insertQuery, history, g)
 	if err != nil {
 		return fail(err)
 	}
@@ -207,66 +269,140 @@ func insertMigration(db *sql.DB, insertQuery string, history historyModel, g *go
 	return rw, nil
 }
 
-func executeScript(db *sql.DB, history historyModel, g *goFlywayRunner) (int64, error) {
+// executeScript Execute the script of history in a transaction. partial reports that the failed script may have been
+// partially applied, by statements committed implicitly on dialects without transactional DDL
+func executeScript(ctx context.Context, db Executor, history historyModel, g *goFlywayRunner) (rowsAffected int64, partial bool, err error) {
 
 	scriptFile := fmt.Sprintf("%s/%s", g.config.Location, history.Script)
 
 	b, err := os.ReadFile(scriptFile)
 	if err != nil {
-		return 0, err
+		return 0, false, err
 	}
 
-	query := string(b)
+	query := replacePlaceholders(string(b), g.config.Placeholders)
 
-	tx, err := db.Begin()
+	timeout, err := g.scriptTimeout(query)
 	if err != nil {
-		return fail(err)
+		return 0, false, fmt.Errorf("invalid timeout directive in %s: %w", history.Script, err)
+	}
+
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	failScript := func(err error, partial bool) (int64, bool, error) {
+		_, err = fail(err)
+		return 0, partial, err
+	}
+
+	tx, err := g.beginTx(ctx, db)
+	if err != nil {
+		return failScript(err, false)
 	}
 	defer tx.Rollback()
 
-	// r1, err := tx.Exec(query)
-	r1, err := queryExecutor(tx, query, g)
+	searchPath := g.dialect.SearchPath(g.schemas())
+	if len(searchPath) > 0 {
+		if _, err = tx.ExecContext(ctx, searchPath); err != nil {
+			return failScript(err, false)
+		}
+	}
+
+	r1, executed, err := queryExecutor(ctx, tx.Tx, query, g)
 	if err != nil {
-		return fail(err)
+		return failScript(g.timeoutError(ctx, timeout, err), executed > 0 && !supportsTransactionalDDL(g.dialect))
 	}
 
 	rw, err := r1.RowsAffected()
 	if err != nil {
-		printWarningLog(fmt.Sprintf("warning: %v", err))
+		g.warn(fmt.Sprintf("warning: %v", err))
 	}
 	// Commit the transaction.
 	if err = tx.Commit(); err != nil {
-		return fail(err)
+		return failScript(g.timeoutError(ctx, timeout, err), false)
 	}
 
-	return rw, nil
+	return rw, false, nil
+}
+
+// scriptTimeout Returns the timeout of the "-- goflyway:timeout=" directive of the script, or ScriptTimeout
+func (g *goFlywayRunner) scriptTimeout(query string) (time.Duration, error) {
+
+	m := regexTimeoutDirective.FindStringSubmatch(query)
+	if m == nil {
+		return g.config.ScriptTimeout, nil
+	}
+
+	timeout, err := time.ParseDuration(m[1])
+	if err != nil {
+		return 0, err
+	}
+
+	if timeout < 0 {
+		return 0, fmt.Errorf("negative timeout %s", m[1])
+	}
+
+	return timeout, nil
 }
 
-func queryExecutor(tx *sql.Tx, query string, g *goFlywayRunner) (sql.Result, error) {
+// timeoutError Returns a TimeoutError when err was caused by the script timeout or by a session timeout of the
+// dialect, err otherwise
+func (g *goFlywayRunner) timeoutError(ctx context.Context, timeout time.Duration, err error) error {
 
-	if g.config.Driver == POSTGRES {
-		return tx.Exec(query)
+	if timeout > 0 && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return &TimeoutError{Setting: "script timeout", Timeout: timeout, Err: err}
 	}
 
-	if g.config.Driver == MYSQL {
-		// at moment it's not possible to create migration with multiple statements
-		return tx.Exec(query)
+	d, ok := g.dialect.(SessionTimeoutDialect)
+	if !ok {
+		return err
 	}
 
-	if g.config.Driver == MSSQLSERVER {
-		return tx.Exec(query)
+	setting, lock := d.ExceededTimeout(err)
+	if len(setting) <= 0 {
+		return err
 	}
 
-	if g.config.Driver == SQLITE3 {
-		return tx.Exec(query)
+	timeout = g.config.StatementTimeout
+	if lock {
+		timeout = g.config.LockTimeout
 	}
 
-	return nil, fmt.Errorf("no driver found on execute")
+	return &TimeoutError{Setting: setting, Timeout: timeout, Err: err}
+}
+
+// queryExecutor Execute the statements of query in tx, executed is the number of statements that succeeded
+func queryExecutor(ctx context.Context, tx *sql.Tx, query string, g *goFlywayRunner) (result sql.Result, executed int, err error) {
+
+	var rowsAffected int64
+
+	for _, statement := range g.dialect.SplitStatements(query) {
+
+		r, err := tx.ExecContext(ctx, statement)
+		if err != nil {
+			return nil, executed, err
+		}
+		executed++
+
+		if rw, err := r.RowsAffected(); err == nil {
+			rowsAffected += rw
+		}
+	}
+
+	return statementsResult(rowsAffected), executed, nil
 }
 
 func insertExecutor(tx *sql.Tx, insertQuery string, history historyModel, g *goFlywayRunner) (sql.Result, error) {
 
-	if g.config.Driver == MSSQLSERVER {
+	checksum, err := g.checksumParam(history.Checksum)
+	if err != nil {
+		return nil, err
+	}
+
+	if g.dialect.ParamStyle() == ParamNamed {
 
 		return tx.Exec(insertQuery,
 			sql.Named("installed_rank", history.InstalledRank),
@@ -274,7 +410,8 @@ func insertExecutor(tx *sql.Tx, insertQuery string, history historyModel, g *goF
 			sql.Named("description", history.Description),
 			sql.Named("type", history.Type),
 			sql.Named("script", history.Script),
-			sql.Named("checksum", history.Checksum),
+			sql.Named("checksum", checksum),
+			sql.Named("installed_by", history.InstalledBy),
 			sql.Named("execution_time", history.ExecutionTime))
 	}
 
@@ -284,6 +421,56 @@ func insertExecutor(tx *sql.Tx, insertQuery string, history historyModel, g *goF
 		history.Description,
 		history.Type,
 		history.Script,
-		history.Checksum,
+		checksum,
+		history.InstalledBy,
 		history.ExecutionTime)
 }
+
+// statementsResult Rows affected by all statements of a script
+type statementsResult int64
+
+func (r statementsResult) LastInsertId() (int64, error) {
+	return 0, fmt.Errorf("LastInsertId is not supported for migration scripts")
+}
+
+func (r statementsResult) RowsAffected() (int64, error) {
+	return int64(r), nil
+}
+
+// replacePlaceholders Replace the ${name} placeholders of a script with their values
+func replacePlaceholders(script string, placeholders map[string]string) string {
+
+	if len(placeholders) <= 0 {
+		return script
+	}
+
+	return regexPlaceholder.ReplaceAllStringFunc(script, func(p string) string {
+		if v, ok := placeholders[p[2:len(p)-1]]; ok {
+			return v
+		}
+		return p
+	})
+}
+
+// scriptRetries Returns how many times a migration failing with err is retried: ScriptRetries when the dialect
+// classifies err as transient, otherwise the default of the dialect when ScriptRetries is not set. A negative
+// ScriptRetries disables retries
+func (g *goFlywayRunner) scriptRetries(err error) int {
+
+	if g.config.ScriptRetries > 0 && isRetryable(g.dialect, err) {
+		return g.config.ScriptRetries
+	}
+
+	if d, ok := g.dialect.(DefaultRetryDialect); ok && g.config.ScriptRetries == 0 {
+		return d.DefaultRetries(err)
+	}
+
+	return 0
+}
+
+// isRetryable Reports whether the dialect classifies err as a transaction conflict worth retrying
+func isRetryable(d Dialect, err error) bool {
+
+	r, ok := d.(RetryableDialect)
+	return ok && r.IsRetryable(err)
+}
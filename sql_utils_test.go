@@ -0,0 +1,89 @@
+package goflyway
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseInstalledOn(t *testing.T) {
+
+	type InstalledOnExpected struct {
+		Driver   Driver
+		Value    string
+		Expected time.Time
+	}
+
+	utc := time.Date(2023, 9, 10, 14, 1, 2, 0, time.UTC)
+
+	values := []InstalledOnExpected{
+		{Driver: SQLITE3, Value: "2023-09-10 14:01:02", Expected: utc},
+		{Driver: SQLITE3, Value: "2023-09-10T14:01:02Z", Expected: utc},
+		{Driver: SQLITE3, Value: "2023-09-10 11:01:02.000000-03:00", Expected: utc},
+		{Driver: MYSQL, Value: "2023-09-10 14:01:02", Expected: utc},
+		{Driver: MYSQL, Value: "1694354462", Expected: utc},
+		{Driver: MARIADB, Value: "1694354462.500000", Expected: utc.Add(500 * time.Millisecond)},
+		{Driver: POSTGRES, Value: "2023-09-10 16:01:02+02", Expected: utc},
+		{Driver: MSSQLSERVER, Value: "2023-09-10 14:01:02.123", Expected: utc.Add(123 * time.Millisecond)},
+		{Driver: MSSQLSERVER, Value: "2023-09-10 11:01:02.0000000 -03:00", Expected: utc},
+	}
+
+	for _, v := range values {
+		d, err := getDialect(v.Driver)
+		if err != nil {
+			t.Fatalf("expected nil but got error %v", err)
+		}
+
+		res, err := parseInstalledOn(v.Value, timestampLayouts(d))
+		if err != nil {
+			t.Errorf("expected nil but got error %v for value %s of driver %s", err, v.Value, v.Driver)
+			continue
+		}
+
+		if !res.Equal(v.Expected) {
+			t.Errorf("expected time %v but got %v for value %s of driver %s", v.Expected, res, v.Value, v.Driver)
+		}
+	}
+
+	if _, err := parseInstalledOn("yesterday", defaultTimestampLayouts); err == nil {
+		t.Errorf("expected error for unparsable value")
+	}
+}
+
+func TestNullTimestampScan(t *testing.T) {
+
+	utc := time.Date(2023, 9, 10, 14, 1, 2, 0, time.UTC)
+
+	values := []interface{}{
+		utc.In(time.FixedZone("BRT", -3*60*60)),
+		int64(1694354462),
+		float64(1694354462),
+	}
+
+	for _, v := range values {
+		var n nullTimestamp
+		if err := n.Scan(v); err != nil {
+			t.Fatalf("expected nil but got error %v", err)
+		}
+
+		if !n.Valid || !n.Time.Equal(utc) {
+			t.Errorf("expected time %v but got %v for value %v", utc, n.Time, v)
+		}
+	}
+
+	var n nullTimestamp
+	if err := n.Scan([]byte("2023-09-10 14:01:02")); err != nil {
+		t.Fatalf("expected nil but got error %v", err)
+	}
+
+	if n.Valid || n.raw != "2023-09-10 14:01:02" {
+		t.Errorf("expected raw text to be kept for parsing but got %+v", n)
+	}
+
+	if err := n.Scan(nil); err != nil || n.Valid || len(n.raw) > 0 {
+		t.Errorf("expected null timestamp but got %+v, %v", n, err)
+	}
+
+	if err := n.Scan(true); err == nil {
+		t.Errorf("expected error for unsupported type")
+	}
+}
@@ -2,42 +2,92 @@ package goflyway
 
 import (
 	"fmt"
-	"log"
 	"path/filepath"
 	"regexp"
 	"runtime"
 	"strings"
+	"time"
 )
 
 const tableName = "goflyway_schema_history"
 const sqlMigrationPrefix = "V"
 const sqlMigrationSeparator = "__"
-
-var logg = log.Default()
+const migrationTypeSql = "sql"
+const baselineVersionDefault = "1"
+const baselineDescription = "<< GoFlyway Baseline >>"
+
+// historyLayoutVersion is the layout of the schema history tables created by this version of GoFlyway.
+// Layout 2 names the primary key after the table instead of pk_goflyway_sch_hist
+const historyLayoutVersion = 2
+
+const legacyPrimaryKeyName = "pk_goflyway_sch_hist"
+
+// anonymousUser is stored as installed_by when the database has no notion of users
+const anonymousUser = "anonymous"
+
+// ciInstalledByVariables Environment variables holding the user that triggered a pipeline, in lookup order
+var ciInstalledByVariables = []string{
+	"GOFLYWAY_INSTALLED_BY",
+	"GITHUB_ACTOR",
+	"GITLAB_USER_LOGIN",
+	"BUILD_REQUESTEDFOR",
+	"CIRCLE_USERNAME",
+	"BUILDKITE_BUILD_CREATOR",
+	"BUILD_USER_ID",
+}
 
 var regexTableName = regexp.MustCompile(`\[tableName\]`)
+var regexTableNameLiteral = regexp.MustCompile(`\[tableNameLiteral\]`)
+var regexSchemaName = regexp.MustCompile(`\[schemaName\]`)
+var regexSchemaNameLiteral = regexp.MustCompile(`\[schemaNameLiteral\]`)
+var regexSchemaNames = regexp.MustCompile(`\[schemaNames\]`)
+var regexPrimaryKeyName = regexp.MustCompile(`\[primaryKeyName\]`)
+var regexSuccessIndexName = regexp.MustCompile(`\[successIndexName\]`)
+var regexPlaceholder = regexp.MustCompile(`\$\{[^}]+\}`)
+var regexDatabaseName = regexp.MustCompile(`\[databaseName\]`)
+var regexDatabaseOption = regexp.MustCompile(`^[A-Za-z0-9_.\-]+$`)
+var regexDbNamePostgres = regexp.MustCompile(`(^|\s)dbname\s*=\s*('(?:\\.|[^'])*'|\S*)`)
+var regexTimeoutDirective = regexp.MustCompile(`(?m)^[ \t]*--[ \t]*goflyway:timeout[ \t]*=[ \t]*(\S+)[ \t]*$`)
+var regexLintIgnore = regexp.MustCompile(`--[ \t]*goflyway:lint-ignore\b([^\n]*)`)
+var regexDollarQuote = regexp.MustCompile(`^\$([A-Za-z_][A-Za-z0-9_]*)?\$`)
+var regexLintCreateTable = regexp.MustCompile(`(?i)^CREATE (?:(?:GLOBAL |LOCAL )?(?:TEMPORARY |TEMP )|UNLOGGED )?TABLE (?:IF NOT EXISTS )?([^\s(]+)`)
+var regexLintDropTable = regexp.MustCompile(`(?i)^DROP TABLE (?:IF EXISTS )?([^\s,;]+)`)
+var regexLintAlterTable = regexp.MustCompile(`(?i)^ALTER TABLE (?:IF EXISTS )?(?:ONLY )?([^\s(]+) (.*)$`)
+var regexLintCreateIndex = regexp.MustCompile(`(?i)^CREATE (?:UNIQUE )?INDEX( CONCURRENTLY)?\b.*? ON (?:ONLY )?([^\s(]+)`)
+var regexLintUpdate = regexp.MustCompile(`(?i)^(?:UPDATE (?:ONLY )?|DELETE FROM (?:ONLY )?|DELETE )([^\s(]+)`)
+var regexLintWhere = regexp.MustCompile(`(?i)\bWHERE\b`)
+var regexLintNotNull = regexp.MustCompile(`(?i)\bNOT NULL\b`)
+var regexLintColumnDefault = regexp.MustCompile(`(?i)\b(DEFAULT|GENERATED|IDENTITY|AUTO_INCREMENT|AUTOINCREMENT|SERIAL|BIGSERIAL|SMALLSERIAL)\b|\bAS \(`)
+var regexLintAlterColumn = regexp.MustCompile(`(?i)^ALTER (COLUMN )?(\S+) (\w+)( DATA TYPE)?`)
+var regexDDLStatement = regexp.MustCompile(`(?i)^(?:CREATE|ALTER|DROP|RENAME|TRUNCATE|COMMENT ON|GRANT|REVOKE|LOCK|UNLOCK|ANALYZE|OPTIMIZE|REPAIR)\b`)
+var regexNonTransactionalPostgres = regexp.MustCompile(`(?i)^(?:(?:CREATE (?:UNIQUE )?|DROP )INDEX CONCURRENTLY|REINDEX\b.*\bCONCURRENTLY|ALTER TABLE\b.*\bDETACH PARTITION\b.*\bCONCURRENTLY|VACUUM|(?:CREATE|DROP) (?:DATABASE|TABLESPACE)|ALTER SYSTEM)\b`)
+var regexNonTransactionalSqlServer = regexp.MustCompile(`(?i)^(?:(?:CREATE|ALTER|DROP) (?:DATABASE|FULLTEXT (?:CATALOG|INDEX))|BACKUP|RESTORE|RECONFIGURE)\b`)
+var regexNonTransactionalSqlite = regexp.MustCompile(`(?i)^VACUUM\b`)
+var regexMysqlErrorNumber = regexp.MustCompile(`Error (\d+)( \([0-9A-Z]{5}\))?:`)
+var regexLayoutVersion = regexp.MustCompile(`\[layoutVersion\]`)
 var regexVersion = regexp.MustCompile(`^\d((_\d)|(\d))*$`)
-var showWarningLog bool
+var regexFlywayVersion = regexp.MustCompile(`^\d+([._]\d+)*$`)
 
-type driver string
+// Driver identifies the Dialect used to talk to the database, see RegisterDialect
+type Driver string
 
 const (
-	POSTGRES driver = "postgres"
-
-	// connection instance must have `multiStatements` set to true
-	MYSQL       driver = "mysql"
-	MSSQLSERVER driver = "sqlserver"
-	SQLITE3     driver = "sqlite3"
+	POSTGRES    Driver = "postgres"
+	MYSQL       Driver = "mysql"
+	MSSQLSERVER Driver = "sqlserver"
+	SQLITE3     Driver = "sqlite3"
+	COCKROACHDB Driver = "cockroachdb"
+	MARIADB     Driver = "mariadb"
 )
 
-func extractValuesFromScriptName(name string, prefix string, separator string, sufix string) (string, string, error) {
+func extractValuesFromScriptName(name string, prefix string, separator string, sufix string, versionPattern *regexp.Regexp) (string, string, error) {
 
 	if !strings.Contains(name, separator) {
 		return "", "", fmt.Errorf("migration '%s' does not contains separator '%s'", name, separator)
 	}
 
 	version := name[len(prefix):strings.Index(name, separator)]
-	if len(version) <= 0 || !regexVersion.MatchString(version) {
+	if len(version) <= 0 || !versionPattern.MatchString(version) {
 		return "", "", fmt.Errorf("invalid version '%s' for migration '%s'", version, name)
 	}
 	version = strings.ReplaceAll(version, "_", ".")
@@ -60,13 +110,17 @@ func findMigrationByVersion(migrations []historyModel, version string) *historyM
 	return nil
 }
 
-func findMigrationIndexByVersion(migrations []historyModel, version string) int {
-	for i, m := range migrations {
-		if m.Version == version {
-			return i
+// findLatestVersion Returns the highest applied version, compared numerically, empty when nothing was applied
+func findLatestVersion(migrations []historyModel) string {
+
+	latest := ""
+	for _, m := range migrations {
+		if len(latest) <= 0 || compareVersions(m.Version, latest) > 0 {
+			latest = m.Version
 		}
 	}
-	return -1
+
+	return latest
 }
 
 func findLocalMigrationsByVersion(migrations []localScript, version string) []localScript {
@@ -100,20 +154,38 @@ func findLargestInstalledRank(migrations []historyModel) int {
 	return largest
 }
 
-func getScriptNames(localMigrations []localScript) string {
+func getScriptNames(localMigrations []localScript) []string {
 	s := []string{}
 	for _, m := range localMigrations {
 		s = append(s, m.Script)
 	}
 
-	return strings.Join(s, " ")
+	return s
 }
 
-func printWarningLog(message string) {
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func containsLintRule(rules []LintRule, rule LintRule) bool {
+	for _, r := range rules {
+		if r == rule {
+			return true
+		}
+	}
+	return false
+}
 
-	if showWarningLog {
-		logg.Println(message)
+func minDuration(a time.Duration, b time.Duration) time.Duration {
+	if a < b {
+		return a
 	}
+	return b
 }
 
 func getWorkPath() string {